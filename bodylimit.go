@@ -0,0 +1,99 @@
+package apihandler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// limitedBody struct wraps the request body limited by http.MaxBytesReader to
+// record if the limit was exceeded while the handler was reading it.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+// Read method reads from the wrapped body, recording if it returns an
+// *http.MaxBytesError.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter struct wraps an http.ResponseWriter to replace the response
+// of the handler by the one of the too large handler once the request body
+// limit is exceeded.
+type bodyLimitWriter struct {
+	*responseWriter
+	body     *limitedBody
+	req      *http.Request
+	tooLarge http.HandlerFunc
+	handled  bool
+}
+
+// respondTooLarge method responds using the too large handler if the request
+// body limit was exceeded and the response was not written yet. It returns if
+// the limit was exceeded, so the writes of the handler must be discarded.
+func (w *bodyLimitWriter) respondTooLarge() bool {
+	if !w.body.exceeded {
+		return false
+	}
+	if !w.handled && !w.written() {
+		w.handled = true
+		w.tooLarge(w.responseWriter, w.req)
+	}
+	return true
+}
+
+// WriteHeader method writes the provided status code unless the request body
+// limit was exceeded.
+func (w *bodyLimitWriter) WriteHeader(status int) {
+	if !w.respondTooLarge() {
+		w.responseWriter.WriteHeader(status)
+	}
+}
+
+// Write method writes the provided data unless the request body limit was
+// exceeded, reporting it as written to keep the handler working as usual.
+func (w *bodyLimitWriter) Write(data []byte) (int, error) {
+	if w.respondTooLarge() {
+		return len(data), nil
+	}
+	return w.responseWriter.Write(data)
+}
+
+// limitBody method wraps the provided handler limiting the request body to
+// the provided number of bytes using http.MaxBytesReader. If the request
+// 'Content-Length' exceeds the limit, the handler is not called. If the limit
+// is exceeded while the handler reads the body, its response is replaced. In
+// both cases, the configured RequestEntityTooLargeHandler or a 413 HTTP error
+// is used to respond.
+func (m *Handler) limitBody(handler http.HandlerFunc, limit int64) http.HandlerFunc {
+	tooLarge := m.tooLarge
+	if tooLarge == nil {
+		tooLarge = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		}
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			tooLarge(w, r)
+			return
+		}
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+		r.Body = body
+		lw := &bodyLimitWriter{
+			responseWriter: &responseWriter{ResponseWriter: w},
+			body:           body,
+			req:            r,
+			tooLarge:       tooLarge,
+		}
+		handler(lw, r)
+		// respond if the handler exceeded the limit without writing
+		lw.respondTooLarge()
+	}
+}
@@ -0,0 +1,80 @@
+package apihandler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// readBodyHandler function reads the whole request body and responds with a
+// 400 HTTP error if it fails, as a regular handler would do.
+func readBodyHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, _ = w.Write(body)
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	handler := NewHandler(&Config{
+		MaxBodyBytes: 8,
+		RequestEntityTooLargeHandler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			_, _ = w.Write([]byte("custom too large"))
+		},
+	})
+	_ = handler.Post("/upload", readBodyHandler)
+	_ = handler.Post("/large", readBodyHandler, WithMaxBody(32))
+
+	serve := func(path, body string, contentLength int64) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.ContentLength = contentLength
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	// body within the limit
+	res := serve("/upload", "small", 5)
+	if res.Code != http.StatusOK || res.Body.String() != "small" {
+		t.Fatalf("expected 200 'small', got %d %q", res.Code, res.Body.String())
+	}
+	// oversized body detected while reading (unknown content length)
+	res = serve("/upload", "this body is too large", -1)
+	if res.Code != http.StatusRequestEntityTooLarge || res.Body.String() != "custom too large" {
+		t.Fatalf("expected the custom handler, got %d %q", res.Code, res.Body.String())
+	}
+	// oversized content length rejected before calling the handler
+	res = serve("/upload", "this body is too large", 22)
+	if res.Code != http.StatusRequestEntityTooLarge || res.Body.String() != "custom too large" {
+		t.Fatalf("expected the custom handler, got %d %q", res.Code, res.Body.String())
+	}
+	// the route limit replaces the handler one
+	res = serve("/large", "this body is too large", -1)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestMaxBodyBytesDefault(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Post("/upload", readBodyHandler, WithMaxBody(4))
+	// the handler ignores the read error and does not write anything
+	_ = handler.Post("/silent", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+	}, WithMaxBody(4))
+
+	for _, path := range []string{"/upload", "/silent"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("too large"))
+		req.ContentLength = -1
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 for %s, got %d", path, res.Code)
+		}
+	}
+}
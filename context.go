@@ -0,0 +1,67 @@
+package apihandler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// contextKey type is used to define the keys of the values that the package
+// stores in the request context, avoiding collisions with other packages.
+type contextKey string
+
+// localeKey is the context key to store the locale selected by the Locale
+// middleware.
+const localeKey contextKey = "locale"
+
+// routeKey is the context key to store the RouteContext of the route that
+// matches with the request.
+const routeKey contextKey = "route"
+
+// RouteContext struct contains the information of the route that matches with
+// a request: its method, its path template, its tags, the named arguments
+// decoded from the request URI, the level of its access logs and its key.
+type RouteContext struct {
+	Method   string
+	Path     string
+	Tags     []string
+	Params   map[string]string
+	LogLevel slog.Level
+	Key      string
+}
+
+// RequestRoute function returns the RouteContext of the route that matches
+// with the request of the provided context. It returns false if the context
+// does not belong to a request served by a route of a Handler.
+func RequestRoute(ctx context.Context) (RouteContext, bool) {
+	route, ok := ctx.Value(routeKey).(RouteContext)
+	return route, ok
+}
+
+// RouteKey function returns the key assigned using WithRouteKey to the route
+// that matches with the request of the provided context. It returns an empty
+// string if the route has no key or the context does not belong to a request
+// served by a route of a Handler.
+func RouteKey(ctx context.Context) string {
+	route, _ := RequestRoute(ctx)
+	return route.Key
+}
+
+// requestIDKey is the context key to store the request identifier assigned by
+// the RequestID middleware.
+const requestIDKey contextKey = "request_id"
+
+// startTimeKey is the context key to store the time when the request was
+// received by the Handler.
+const startTimeKey contextKey = "start_time"
+
+// Elapsed function returns the time elapsed since the request of the provided
+// context was received by the Handler. It returns zero if the context does not
+// belong to a request served by a Handler.
+func Elapsed(ctx context.Context) time.Duration {
+	start, ok := ctx.Value(startTimeKey).(time.Time)
+	if !ok {
+		return 0
+	}
+	return time.Since(start)
+}
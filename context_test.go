@@ -0,0 +1,74 @@
+package apihandler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestElapsed(t *testing.T) {
+	if elapsed := Elapsed(context.Background()); elapsed != 0 {
+		t.Fatalf("expected 0, got %s", elapsed)
+	}
+
+	var elapsed time.Duration
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		elapsed = Elapsed(r.Context())
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	if elapsed < 10*time.Millisecond {
+		t.Fatalf("expected at least 10ms, got %s", elapsed)
+	}
+}
+
+func TestRequestRoute(t *testing.T) {
+	if _, ok := RequestRoute(context.Background()); ok {
+		t.Fatal("expected false, got true")
+	}
+
+	var route RouteContext
+	var ok bool
+	handler := NewHandler(nil)
+	_ = handler.Get("/api/{version}/user/{id}", func(w http.ResponseWriter, r *http.Request) {
+		route, ok = RequestRoute(r.Context())
+	}, WithTags("users", "public"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v2/user/42", nil))
+	if !ok {
+		t.Fatal("expected true, got false")
+	}
+	if route.Method != http.MethodGet {
+		t.Fatalf("expected '%s', got '%s'", http.MethodGet, route.Method)
+	}
+	if route.Path != "/api/{version}/user/{id}" {
+		t.Fatalf("expected '/api/{version}/user/{id}', got '%s'", route.Path)
+	}
+	if len(route.Tags) != 2 || route.Tags[0] != "users" || route.Tags[1] != "public" {
+		t.Fatalf("expected [users public], got %v", route.Tags)
+	}
+	if route.Params["version"] != "v2" || route.Params["id"] != "42" {
+		t.Fatalf("expected version 'v2' and id '42', got %v", route.Params)
+	}
+}
+
+func TestRouteKey(t *testing.T) {
+	keys := []string{}
+	shared := func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, RouteKey(r.Context()))
+	}
+	handler := NewHandler(nil)
+	_ = handler.GetKeyed("/a", "keyA", shared)
+	_ = handler.GetKeyed("/b", "keyB", shared)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/b", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	if len(keys) != 2 || keys[0] != "keyB" || keys[1] != "keyA" {
+		t.Fatalf("expected [keyB keyA], got %v", keys)
+	}
+	if key := RouteKey(context.Background()); key != "" {
+		t.Fatalf("expected empty key, got '%s'", key)
+	}
+}
@@ -0,0 +1,100 @@
+package apihandler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CookieOptions struct contains the attributes that the SecureCookies
+// middleware adds to the cookies set by the handlers if they are missing.
+// SameSite attribute is not added if it is zero (http.SameSiteDefaultMode).
+type CookieOptions struct {
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// secure method returns the provided 'Set-Cookie' header value adding the
+// attributes of the options that are missing.
+func (opts CookieOptions) secure(cookie string) string {
+	var hasSecure, hasHttpOnly, hasSameSite bool
+	for _, attr := range strings.Split(cookie, ";")[1:] {
+		name, _, _ := strings.Cut(strings.TrimSpace(attr), "=")
+		switch strings.ToLower(name) {
+		case "secure":
+			hasSecure = true
+		case "httponly":
+			hasHttpOnly = true
+		case "samesite":
+			hasSameSite = true
+		}
+	}
+	if opts.Secure && !hasSecure {
+		cookie += "; Secure"
+	}
+	if opts.HttpOnly && !hasHttpOnly {
+		cookie += "; HttpOnly"
+	}
+	if !hasSameSite {
+		switch opts.SameSite {
+		case http.SameSiteLaxMode:
+			cookie += "; SameSite=Lax"
+		case http.SameSiteStrictMode:
+			cookie += "; SameSite=Strict"
+		case http.SameSiteNoneMode:
+			cookie += "; SameSite=None"
+		}
+	}
+	return cookie
+}
+
+// cookiesResponseWriter struct wraps an http.ResponseWriter to secure the
+// 'Set-Cookie' headers before they are written.
+type cookiesResponseWriter struct {
+	*responseWriter
+	opts CookieOptions
+}
+
+// secureCookies method updates the 'Set-Cookie' headers of the response
+// adding the missing attributes, before they are written.
+func (w *cookiesResponseWriter) secureCookies() {
+	if w.written() {
+		return
+	}
+	cookies := w.Header().Values("Set-Cookie")
+	for i, cookie := range cookies {
+		cookies[i] = w.opts.secure(cookie)
+	}
+}
+
+// WriteHeader method secures the cookies and writes the provided status code.
+func (w *cookiesResponseWriter) WriteHeader(status int) {
+	w.secureCookies()
+	w.responseWriter.WriteHeader(status)
+}
+
+// Write method secures the cookies and writes the provided data.
+func (w *cookiesResponseWriter) Write(data []byte) (int, error) {
+	w.secureCookies()
+	return w.responseWriter.Write(data)
+}
+
+// Flush method secures the cookies and flushes the wrapped writer.
+func (w *cookiesResponseWriter) Flush() {
+	w.secureCookies()
+	w.responseWriter.Flush()
+}
+
+// SecureCookies function returns a Middleware that adds the attributes of the
+// provided options to the cookies set by the handlers if they are missing,
+// before the response headers are written.
+func SecureCookies(opts CookieOptions) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			cw := &cookiesResponseWriter{responseWriter: &responseWriter{ResponseWriter: w}, opts: opts}
+			next(cw, r)
+			// secure the cookies if the handler did not write anything
+			cw.secureCookies()
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecureCookies(t *testing.T) {
+	opts := CookieOptions{Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode}
+	handler := SecureCookies(opts)(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark", SameSite: http.SameSiteStrictMode})
+		if r.URL.Path == "/write" {
+			_, _ = w.Write([]byte("ok"))
+		}
+	})
+
+	for _, path := range []string{"/write", "/empty"} {
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(http.MethodGet, path, nil))
+		cookies := res.Result().Header.Values("Set-Cookie")
+		if len(cookies) != 2 {
+			t.Fatalf("expected 2 cookies, got %v", cookies)
+		}
+		if cookies[0] != "session=abc; Secure; HttpOnly; SameSite=Lax" {
+			t.Fatalf("expected secured session cookie, got '%s'", cookies[0])
+		}
+		if cookies[1] != "theme=dark; SameSite=Strict; Secure; HttpOnly" {
+			t.Fatalf("expected secured theme cookie, got '%s'", cookies[1])
+		}
+	}
+}
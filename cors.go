@@ -0,0 +1,55 @@
+package apihandler
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// setCORSHeaders method sets the CORS headers of the response to the provided
+// request according to the Handler config. By default, it allows any origin
+// and any header using the '*' wildcard. If the allowed origins are defined,
+// the request origin is echoed only if it is one of them (varying the
+// response by origin), and, if the credentials are also allowed, the
+// credentials header is set and, if no allowed headers are defined, the
+// requested headers are echoed too.
+func (m *Handler) setCORSHeaders(res http.ResponseWriter, req *http.Request) {
+	header := res.Header()
+	origin, headers := "*", "*"
+	if len(m.allowHeaders) > 0 {
+		headers = strings.Join(m.allowHeaders, ", ")
+	}
+	if len(m.allowOrigins) > 0 {
+		header.Add("Vary", "Origin")
+		origin = req.Header.Get("Origin")
+		if !slices.Contains(m.allowOrigins, origin) {
+			origin = ""
+		} else if m.credentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+			if len(m.allowHeaders) == 0 {
+				headers = req.Header.Get("Access-Control-Request-Headers")
+			}
+		}
+	}
+	if origin != "" {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if headers != "" {
+		header.Set("Access-Control-Allow-Headers", headers)
+	}
+	if len(m.exposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(m.exposeHeaders, ", "))
+	}
+}
+
+// preflightMethods method returns the sorted list of methods of the enabled
+// routes that match the provided path, delegating to the sub-Handler mounted
+// at its prefix if any, so the CORS preflight responses reflect the routes
+// that will serve the actual request. The implicit HEAD routes are only
+// included if the implicit flag provided is true.
+func (m *Handler) preflightMethods(path string, implicit bool) []string {
+	if mnt, rest, ok := m.mountFor(path); ok {
+		return mnt.sub.preflightMethods(rest, implicit)
+	}
+	return m.methodsFor(path, implicit)
+}
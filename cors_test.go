@@ -0,0 +1,140 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORSCredentials(t *testing.T) {
+	handler := NewHandler(&Config{
+		CORS:             true,
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		AllowedHeaders:   []string{"Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+	})
+	_ = handler.Get(testPath, testHandler)
+
+	req := httptest.NewRequest(http.MethodGet, testURI, nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Headers":     "Content-Type, X-CSRF-Token",
+		"Access-Control-Expose-Headers":    "X-Request-ID",
+		"Vary":                             "Origin",
+	}
+	for header, value := range expected {
+		if got := res.Header().Get(header); got != value {
+			t.Fatalf("expected '%s' for %s, got '%s'", value, header, got)
+		}
+	}
+
+	// other origins are not allowed
+	req = httptest.NewRequest(http.MethodGet, testURI, nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	for _, header := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials"} {
+		if value := res.Header().Get(header); value != "" {
+			t.Fatalf("expected no %s, got '%s'", header, value)
+		}
+	}
+
+	// without allowed headers, the requested ones are echoed
+	handler = NewHandler(&Config{CORS: true, AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+	req = httptest.NewRequest(http.MethodOptions, testURI, nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if value := res.Header().Get("Access-Control-Allow-Headers"); value != "Authorization" {
+		t.Fatalf("expected 'Authorization', got '%s'", value)
+	}
+
+	// the default config uses the wildcards, and the credentials are ignored
+	// without allowed origins
+	for _, cfg := range []*Config{{CORS: true}, {CORS: true, AllowCredentials: true}} {
+		handler = NewHandler(cfg)
+		req = httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if value := res.Header().Get("Access-Control-Allow-Origin"); value != "*" {
+			t.Fatalf("expected '*', got '%s'", value)
+		}
+		if value := res.Header().Get("Access-Control-Allow-Credentials"); value != "" {
+			t.Fatalf("expected no credentials header, got '%s'", value)
+		}
+	}
+}
+
+func TestCORSMaxAge(t *testing.T) {
+	handler := NewHandler(&Config{CORS: true, CORSMaxAge: 10 * time.Minute})
+	_ = handler.Get(testPath, testHandler)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodOptions, testURI, nil))
+	if value := res.Header().Get("Access-Control-Max-Age"); value != "600" {
+		t.Fatalf("expected '600', got '%s'", value)
+	}
+
+	handler = NewHandler(&Config{CORS: true})
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodOptions, testURI, nil))
+	if value := res.Header().Get("Access-Control-Max-Age"); value != "" {
+		t.Fatalf("expected no max age, got '%s'", value)
+	}
+}
+
+func TestPreflightRequestedMethod(t *testing.T) {
+	preflight := func(handler *Handler, path, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Access-Control-Request-Method", method)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	sub := NewHandler(nil)
+	_ = sub.Put("/users/{id}", testHandler)
+	for _, strict := range []bool{false, true} {
+		handler := NewHandler(&Config{CORS: true, StrictPreflight: strict})
+		_ = handler.Get(testPath, testHandler)
+		handler.Mount("/admin", sub)
+
+		// allowed requested method
+		res := preflight(handler, testURI, http.MethodGet)
+		if res.Code != http.StatusOK || res.Header().Get("Access-Control-Allow-Methods") != "GET" {
+			t.Fatalf("expected 200 allowing GET, got %d %q", res.Code, res.Header().Get("Access-Control-Allow-Methods"))
+		}
+		// the implicit HEAD route is allowed but not listed
+		if res = preflight(handler, testURI, http.MethodHead); res.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", res.Code)
+		}
+		// the routes of the mounted sub-Handler are reflected
+		res = preflight(handler, "/admin/users/42", http.MethodPut)
+		if res.Code != http.StatusOK || res.Header().Get("Access-Control-Allow-Methods") != "PUT" {
+			t.Fatalf("expected 200 allowing PUT, got %d %q", res.Code, res.Header().Get("Access-Control-Allow-Methods"))
+		}
+
+		// disallowed requested method is never listed, and rejected in
+		// strict mode
+		expected := http.StatusOK
+		if strict {
+			expected = http.StatusForbidden
+		}
+		res = preflight(handler, testURI, http.MethodDelete)
+		if res.Code != expected || strings.Contains(res.Header().Get("Access-Control-Allow-Methods"), http.MethodDelete) {
+			t.Fatalf("expected %d without DELETE, got %d %q", expected, res.Code, res.Header().Get("Access-Control-Allow-Methods"))
+		}
+		res = preflight(handler, "/admin/users/42", http.MethodGet)
+		if res.Code != expected || res.Header().Get("Access-Control-Allow-Methods") != "PUT" {
+			t.Fatalf("expected %d allowing PUT, got %d %q", expected, res.Code, res.Header().Get("Access-Control-Allow-Methods"))
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package apihandler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// DefaultCSRFCookie constant contains the default name of the cookie used by
+// the CSRF middleware to store the signed token.
+const DefaultCSRFCookie = "csrf_token"
+
+// DefaultCSRFHeader constant contains the default name of the header used by
+// the CSRF middleware to receive and issue the token.
+const DefaultCSRFHeader = "X-CSRF-Token"
+
+// csrfTokenKey is the context key to store the token issued by the CSRF
+// middleware.
+const csrfTokenKey contextKey = "csrf_token"
+
+// CSRFOptions struct contains the config of the CSRF middleware. The Secret is
+// used to sign the token stored in the cookie, if it is empty a random one is
+// generated, so the tokens issued are only valid while the process is running.
+// CookieName and HeaderName default to 'csrf_token' and 'X-CSRF-Token'.
+type CSRFOptions struct {
+	Secret     []byte
+	CookieName string
+	HeaderName string
+}
+
+// sign method returns the cookie value for the provided token, which contains
+// the token and its HMAC-SHA256 signature separated by a dot.
+func (opts CSRFOptions) sign(token string) string {
+	mac := hmac.New(sha256.New, opts.Secret)
+	mac.Write([]byte(token))
+	return token + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify method returns the token contained in the provided cookie value if
+// its signature is valid.
+func (opts CSRFOptions) verify(value string) (string, bool) {
+	token, _, ok := strings.Cut(value, ".")
+	if !ok || token == "" || !hmac.Equal([]byte(opts.sign(token)), []byte(value)) {
+		return "", false
+	}
+	return token, true
+}
+
+// CSRF function returns a Middleware that protects the requests against
+// cross-site request forgery using the double-submit cookie pattern. For
+// POST, PUT, PATCH and DELETE requests, the token received in the configured
+// header must match the one stored in the signed cookie, otherwise the
+// request is rejected with a 403 HTTP error. For other methods, the token of
+// the cookie is issued (generating and setting a new one if it is missing or
+// invalid) in the configured response header and stored in the request
+// context, where it can be read using CSRFToken.
+func CSRF(opts CSRFOptions) Middleware {
+	if opts.CookieName == "" {
+		opts.CookieName = DefaultCSRFCookie
+	}
+	if opts.HeaderName == "" {
+		opts.HeaderName = DefaultCSRFHeader
+	}
+	if len(opts.Secret) == 0 {
+		opts.Secret = make([]byte, 32)
+		_, _ = rand.Read(opts.Secret)
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var token string
+			if cookie, err := r.Cookie(opts.CookieName); err == nil {
+				token, _ = opts.verify(cookie.Value)
+			}
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+				received := r.Header.Get(opts.HeaderName)
+				if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(received)) != 1 {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			default:
+				if token == "" {
+					buf := make([]byte, 32)
+					if _, err := rand.Read(buf); err != nil {
+						http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+						return
+					}
+					token = hex.EncodeToString(buf)
+					http.SetCookie(w, &http.Cookie{
+						Name:     opts.CookieName,
+						Value:    opts.sign(token),
+						Path:     "/",
+						SameSite: http.SameSiteLaxMode,
+					})
+				}
+				w.Header().Set(opts.HeaderName, token)
+			}
+			next(w, r.WithContext(context.WithValue(r.Context(), csrfTokenKey, token)))
+		}
+	}
+}
+
+// CSRFToken function returns the token issued or validated by the CSRF
+// middleware from the provided context. It returns an empty string if the
+// request was not served through the CSRF middleware.
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey).(string)
+	return token
+}
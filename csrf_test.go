@@ -0,0 +1,99 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	opts := CSRFOptions{Secret: []byte("secret"), CookieName: "csrf", HeaderName: "X-Token"}
+	var ctxToken string
+	handler := CSRF(opts)(func(w http.ResponseWriter, r *http.Request) {
+		ctxToken = CSRFToken(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// GET issues the token
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	token := res.Header().Get("X-Token")
+	if token == "" || token != ctxToken {
+		t.Fatalf("expected the token to be issued, got %q and %q", token, ctxToken)
+	}
+	cookies := res.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf" {
+		t.Fatalf("expected the csrf cookie, got %v", cookies)
+	}
+	cookie := cookies[0]
+
+	// GET with a valid cookie reuses the token
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Header().Get("X-Token") != token || len(res.Result().Cookies()) != 0 {
+		t.Fatalf("expected the token to be reused")
+	}
+
+	// valid token
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookie)
+	req.Header.Set("X-Token", token)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	// missing token
+	req = httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.AddCookie(cookie)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", res.Code)
+	}
+
+	// mismatched token
+	req = httptest.NewRequest(http.MethodPut, "/", nil)
+	req.AddCookie(cookie)
+	req.Header.Set("X-Token", "other")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", res.Code)
+	}
+
+	// forged cookie signature
+	req = httptest.NewRequest(http.MethodPatch, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "csrf", Value: "forged.signature"})
+	req.Header.Set("X-Token", "forged")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", res.Code)
+	}
+}
+
+func TestCSRFDefaults(t *testing.T) {
+	handler := CSRF(CSRFOptions{})(func(w http.ResponseWriter, r *http.Request) {})
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	token := res.Header().Get(DefaultCSRFHeader)
+	cookies := res.Result().Cookies()
+	if token == "" || len(cookies) != 1 || cookies[0].Name != DefaultCSRFCookie {
+		t.Fatalf("expected the default cookie and header, got %q and %v", token, cookies)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookies[0])
+	req.Header.Set(DefaultCSRFHeader, token)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package apihandler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errDecompressedTooLarge error is returned when the decompressed body of a
+// request exceeds the configured limit.
+var errDecompressedTooLarge = errors.New("decompressed body too large")
+
+// decompressedBody struct contains the reader of a decompressed request body
+// and closes both the decompressor and the original body when it is closed.
+type decompressedBody struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+// Close method closes the decompressor and the original request body.
+func (b *decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if bodyErr := b.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// decompressRequest function replaces the body of the provided request with
+// its decompressed version if it is encoded with gzip or deflate, according
+// to its 'Content-Encoding' header. If the provided limit is greater than
+// zero, the body is decompressed eagerly and errDecompressedTooLarge is
+// returned if it exceeds the limit, which protects against decompression
+// bombs. Otherwise, the body is decompressed as it is read.
+func decompressRequest(req *http.Request, limit int64) error {
+	var (
+		reader io.ReadCloser
+		err    error
+	)
+	switch strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(req.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(req.Body)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error decompressing body: %w", err)
+	}
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	body := &decompressedBody{ReadCloser: reader, body: req.Body}
+	if limit <= 0 {
+		req.Body = body
+		req.ContentLength = -1
+		return nil
+	}
+	defer body.Close()
+	buf, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return fmt.Errorf("error decompressing body: %w", err)
+	}
+	if int64(len(buf)) > limit {
+		return errDecompressedTooLarge
+	}
+	req.Body = io.NopCloser(bytes.NewReader(buf))
+	req.ContentLength = int64(len(buf))
+	req.Header.Set("Content-Length", strconv.Itoa(len(buf)))
+	return nil
+}
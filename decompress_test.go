@@ -0,0 +1,61 @@
+package apihandler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxDecompressedBytes(t *testing.T) {
+	handler := NewHandler(&Config{DecompressRequests: true, MaxDecompressedBytes: 1024})
+	_ = handler.Post("/upload", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(body)
+	})
+
+	compressors := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+	for encoding, compressor := range compressors {
+		compress := func(data string) *bytes.Buffer {
+			buf := &bytes.Buffer{}
+			w := compressor(buf)
+			_, _ = w.Write([]byte(data))
+			_ = w.Close()
+			return buf
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/upload", compress("small payload"))
+		req.Header.Set("Content-Encoding", encoding)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", encoding, res.Code)
+		}
+		if body := res.Body.String(); body != "small payload" {
+			t.Fatalf("expected 'small payload' for %s, got '%s'", encoding, body)
+		}
+
+		bomb := compress(strings.Repeat("0", 1<<18))
+		if bomb.Len() > 1024 {
+			t.Fatalf("expected compressed payload under the limit, got %d bytes", bomb.Len())
+		}
+		req = httptest.NewRequest(http.MethodPost, "/upload", bomb)
+		req.Header.Set("Content-Encoding", encoding)
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		if res.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("expected 413 for %s, got %d", encoding, res.Code)
+		}
+	}
+}
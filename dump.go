@@ -0,0 +1,71 @@
+package apihandler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+)
+
+// maxDumpBodySize constant defines the maximum number of bytes of the request
+// and response bodies included in the dumps of the SampledDump middleware.
+const maxDumpBodySize = 64 << 10
+
+// dumpResponseWriter struct wraps an http.ResponseWriter to capture the first
+// bytes of the response body, up to maxDumpBodySize, to dump it.
+type dumpResponseWriter struct {
+	*responseWriter
+	body bytes.Buffer
+}
+
+// Write method writes the provided data capturing it if the limit of captured
+// bytes is not reached yet.
+func (w *dumpResponseWriter) Write(data []byte) (int, error) {
+	n, err := w.responseWriter.Write(data)
+	if remaining := maxDumpBodySize - w.body.Len(); remaining > 0 && n > 0 {
+		w.body.Write(data[:min(n, remaining)])
+	}
+	return n, err
+}
+
+// SampledDump function returns a Middleware that, with the probability
+// provided (between 0 and 1), dumps the request (method, URI, headers and
+// body) and the response (status, headers and body) and calls the provided
+// sink with the dump once the wrapped handler returns. Only the first 64KB of
+// every body are included, and the request body is still fully available for
+// the wrapped handler.
+func SampledDump(rate float64, sink func(dump []byte)) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+				next(w, r)
+				return
+			}
+			var reqBody []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, maxDumpBodySize))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+			dump, _ := httputil.DumpRequest(r, false)
+			dump = append(dump, reqBody...)
+
+			rw := &dumpResponseWriter{responseWriter: &responseWriter{ResponseWriter: w}}
+			next(rw, r)
+			status := rw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			res := &bytes.Buffer{}
+			fmt.Fprintf(res, "\r\n\r\n%s %d %s\r\n", r.Proto, status, http.StatusText(status))
+			_ = rw.Header().Write(res)
+			res.WriteString("\r\n")
+			res.Write(rw.body.Bytes())
+			sink(append(dump, res.Bytes()...))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package apihandler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSampledDump(t *testing.T) {
+	var dump string
+	handler := SampledDump(1, func(d []byte) { dump = string(d) })(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Test", "dump")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created " + string(body)))
+	})
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodPost, testURI, strings.NewReader("payload")))
+	if body := res.Body.String(); body != "created payload" {
+		t.Fatalf("expected 'created payload', got '%s'", body)
+	}
+	for _, expected := range []string{"POST " + testURI, "payload", "201 Created", "X-Test: dump", "created payload"} {
+		if !strings.Contains(dump, expected) {
+			t.Fatalf("expected '%s' in dump, got '%s'", expected, dump)
+		}
+	}
+
+	dump = ""
+	handler = SampledDump(0, func(d []byte) { dump = string(d) })(testHandler)
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	if dump != "" {
+		t.Fatalf("expected no dump, got '%s'", dump)
+	}
+}
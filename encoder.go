@@ -0,0 +1,111 @@
+package apihandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Encoder interface defines the encoding of the values returned by the
+// ValueHandlers into the response body, and its content type. The Handler
+// uses JSONEncoder by default, which can be replaced using Config.Encoder,
+// for example, with a MsgPack or XML one.
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, v any) error
+}
+
+// JSONEncoder struct implements the Encoder interface encoding the values as
+// JSON.
+type JSONEncoder struct{}
+
+// ContentType method returns the JSON content type.
+func (JSONEncoder) ContentType() string {
+	return "application/json"
+}
+
+// Encode method writes the JSON encoding of the provided value to the provided
+// writer.
+func (JSONEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// ValueHandler type defines a route handler that returns the value to respond
+// with, encoded using the configured Encoder, or an error to respond using the
+// configured error handler.
+type ValueHandler func(*http.Request) (any, error)
+
+// statusCoder interface defines the errors that provide the HTTP status code
+// to respond with.
+type statusCoder interface {
+	StatusCode() int
+}
+
+// defaultErrorHandler method responds to the provided error with the status
+// code that it provides, if it implements a 'StatusCode() int' method, or
+// with a 500 HTTP error, which is also reported to the errors channel.
+func (m *Handler) defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	var coder statusCoder
+	if errors.As(err, &coder) {
+		http.Error(w, err.Error(), coder.StatusCode())
+		return
+	}
+	m.report(fmt.Errorf("error handling '%s': %w", r.URL.Path, err))
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// HandleValue method registers the provided ValueHandler for the provided
+// method and path, as `Handler.HandleFunc` does. The value returned by the
+// handler is encoded using the configured Encoder, setting its content type,
+// or, if it is nil, the response is a 204 HTTP status without body. If the
+// handler returns an error or the value can not be encoded, the configured
+// Config.ErrorHandler responds to the error.
+func (m *Handler) HandleValue(method, path string, h ValueHandler, opts ...RouteOption) error {
+	return m.HandleFunc(method, path, func(w http.ResponseWriter, r *http.Request) {
+		value, err := h(r)
+		if err != nil {
+			m.onError(w, r, err)
+			return
+		}
+		if value == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body := &bytes.Buffer{}
+		if err := m.encoder.Encode(body, value); err != nil {
+			m.onError(w, r, fmt.Errorf("error encoding response: %w", err))
+			return
+		}
+		w.Header().Set("Content-Type", m.encoder.ContentType())
+		_, _ = w.Write(body.Bytes())
+	}, opts...)
+}
+
+// GetJSON method wraps `Handler.HandleValue` for HTTP method 'GET'. Despite
+// its name, the value is encoded using the configured Encoder.
+func (m *Handler) GetJSON(p string, h ValueHandler, opts ...RouteOption) error {
+	return m.HandleValue(http.MethodGet, p, h, opts...)
+}
+
+// PostJSON method wraps `Handler.HandleValue` for HTTP method 'POST'.
+func (m *Handler) PostJSON(p string, h ValueHandler, opts ...RouteOption) error {
+	return m.HandleValue(http.MethodPost, p, h, opts...)
+}
+
+// PutJSON method wraps `Handler.HandleValue` for HTTP method 'PUT'.
+func (m *Handler) PutJSON(p string, h ValueHandler, opts ...RouteOption) error {
+	return m.HandleValue(http.MethodPut, p, h, opts...)
+}
+
+// PatchJSON method wraps `Handler.HandleValue` for HTTP method 'PATCH'.
+func (m *Handler) PatchJSON(p string, h ValueHandler, opts ...RouteOption) error {
+	return m.HandleValue(http.MethodPatch, p, h, opts...)
+}
+
+// DeleteJSON method wraps `Handler.HandleValue` for HTTP method 'DELETE'.
+func (m *Handler) DeleteJSON(p string, h ValueHandler, opts ...RouteOption) error {
+	return m.HandleValue(http.MethodDelete, p, h, opts...)
+}
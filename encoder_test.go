@@ -0,0 +1,119 @@
+package apihandler
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// xmlEncoder struct implements the Encoder interface encoding the values as
+// XML.
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string { return "application/xml" }
+
+func (xmlEncoder) Encode(w io.Writer, v any) error { return xml.NewEncoder(w).Encode(v) }
+
+// notFoundError type implements an error that provides its status code.
+type notFoundError string
+
+func (e notFoundError) Error() string   { return string(e) + " not found" }
+func (e notFoundError) StatusCode() int { return http.StatusNotFound }
+
+type user struct {
+	XMLName struct{} `json:"-" xml:"user"`
+	ID      string   `json:"id" xml:"id"`
+}
+
+func userHandler(r *http.Request) (any, error) {
+	switch id := r.Header.Get("id"); id {
+	case "missing":
+		return nil, notFoundError("user")
+	case "broken":
+		return nil, errors.New("database down")
+	case "empty":
+		return nil, nil
+	default:
+		return user{ID: id}, nil
+	}
+}
+
+func TestHandleValue(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.GetJSON("/users/{id}", userHandler)
+	_ = handler.PostJSON("/users/{id}", userHandler)
+	serve := func(method, uri string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(method, uri, nil))
+		return res
+	}
+
+	res := serve(http.MethodGet, "/users/42")
+	if res.Code != http.StatusOK || res.Body.String() != "{\"id\":\"42\"}\n" {
+		t.Fatalf("expected 200 with the user, got %d %q", res.Code, res.Body.String())
+	}
+	if value := res.Header().Get("Content-Type"); value != "application/json" {
+		t.Fatalf("expected 'application/json', got '%s'", value)
+	}
+	if res = serve(http.MethodPost, "/users/42"); res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if res = serve(http.MethodGet, "/users/empty"); res.Code != http.StatusNoContent || res.Body.Len() != 0 {
+		t.Fatalf("expected 204 without body, got %d %q", res.Code, res.Body.String())
+	}
+	// the errors with status code are mapped to it
+	if res = serve(http.MethodGet, "/users/missing"); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+	// the rest are reported and responded with 500
+	if res = serve(http.MethodGet, "/users/broken"); res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", res.Code)
+	}
+	select {
+	case err := <-handler.Errors():
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	default:
+		t.Fatal("expected the error to be reported")
+	}
+}
+
+func TestHandleValueCustomEncoder(t *testing.T) {
+	var handled error
+	handler := NewHandler(&Config{
+		Encoder: xmlEncoder{},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			handled = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		},
+	})
+	_ = handler.GetJSON("/users/{id}", userHandler)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if res.Code != http.StatusOK || res.Body.String() != "<user><id>42</id></user>" {
+		t.Fatalf("expected 200 with the XML user, got %d %q", res.Code, res.Body.String())
+	}
+	if value := res.Header().Get("Content-Type"); value != "application/xml" {
+		t.Fatalf("expected 'application/xml', got '%s'", value)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/broken", nil))
+	if res.Code != http.StatusServiceUnavailable || handled == nil {
+		t.Fatalf("expected the custom error handler, got %d %v", res.Code, handled)
+	}
+
+	// the encoder can be provided as option
+	handler = NewHandler(WithEncoder(xmlEncoder{}))
+	_ = handler.GetJSON("/users/{id}", userHandler)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+	if res.Body.String() != "<user><id>7</id></user>" {
+		t.Fatalf("expected the XML user, got %q", res.Body.String())
+	}
+}
@@ -0,0 +1,123 @@
+package apihandler
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// maxStackFrames constant defines the maximum number of frames captured from
+// the stack of a recovered panic when its depth is limited.
+const maxStackFrames = 64
+
+// errorsBufferSize constant defines the size of the buffer of the errors
+// channel of the Handler. When the buffer is full, new errors are dropped.
+const errorsBufferSize = 64
+
+// ErrHandlerPanic error is wrapped by the errors sent to the errors channel of
+// the Handler when a route handler panics and the recovery is enabled.
+var ErrHandlerPanic = errors.New("handler panic")
+
+// ErrPreflightRejected error is wrapped by the errors sent to the errors
+// channel of the Handler when a CORS preflight request is rejected because the
+// requested method is not registered for the requested path.
+var ErrPreflightRejected = errors.New("preflight rejected")
+
+// ErrLimiterStore error is wrapped by the errors sent to the errors channel of
+// the Handler when the LimiterStore fails to decide if a request is allowed.
+var ErrLimiterStore = errors.New("limiter store error")
+
+// ErrRouteConflict error is wrapped by the error returned by `Handler.Merge`
+// when both handlers have routes with the same method and path, and by the
+// errors returned by `Handler.HandleFunc` and `Handler.Merge` when every
+// request URI of the new route would be matched first by a route with a
+// different path.
+var ErrRouteConflict = errors.New("conflicting routes")
+
+// ErrURIParamNotFound error is wrapped by the errors returned by the typed
+// URI param accessors (like URIParamInt) when the argument does not exist.
+var ErrURIParamNotFound = errors.New("uri param not found")
+
+// ErrQueryParamNotFound error is wrapped by the errors returned by the strict
+// query param accessors (like QueryIntStrict) when the param is not present.
+var ErrQueryParamNotFound = errors.New("query param not found")
+
+// ErrUnknownHandler error is wrapped by the errors returned by
+// `Handler.ExportConfig` and `Handler.ImportConfig` when a route has no
+// handler name or its name is not found in the registry provided.
+var ErrUnknownHandler = errors.New("unknown handler")
+
+// ErrUnknownRoute error is wrapped by the error returned by `Handler.URL` when
+// no route has the provided name.
+var ErrUnknownRoute = errors.New("unknown route")
+
+// ErrUnsupportedContentType error is wrapped by the error returned by
+// DecodeJSON when the request 'Content-Type' is not 'application/json'.
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// ErrBodyTooLarge error is wrapped by the error returned by DecodeJSON when
+// the request body exceeds the maximum size.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// Errors method returns the channel where the Handler sends the errors raised
+// while serving requests, like recovered panics, rejected CORS preflights or
+// invalid compressed bodies, and the errors reported by the route handlers
+// using `Handler.Error`. The channel is buffered and the Handler never blocks
+// sending to it, so errors are dropped if the buffer is full.
+func (m *Handler) Errors() <-chan error {
+	return m.errs
+}
+
+// Error method reports the provided error to the errors channel of the
+// Handler, allowing the route handlers to centralize their errors with the
+// ones raised by the Handler. It never blocks, dropping the error if the
+// buffer of the channel is full. Nil errors are ignored.
+func (m *Handler) Error(err error) {
+	if err != nil {
+		m.report(err)
+	}
+}
+
+// report method sends the provided error to the errors channel without
+// blocking, dropping it if the buffer of the channel is full.
+func (m *Handler) report(err error) {
+	select {
+	case m.errs <- err:
+	default:
+	}
+}
+
+// panicStack function returns the formatted stack trace of the current
+// goroutine to be reported with a recovered panic. If the provided depth is
+// zero or negative, the full stack trace is returned, otherwise, only the
+// provided number of frames after the panic call are included, skipping the
+// frames of the runtime package, so the first one is the frame that raised
+// the panic.
+func panicStack(depth int) string {
+	if depth <= 0 {
+		return string(debug.Stack())
+	}
+	pcs := make([]uintptr, maxStackFrames)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	captured := []runtime.Frame{}
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			// discard the frames of the recovery
+			captured = captured[:0]
+		case !strings.HasPrefix(frame.Function, "runtime."):
+			captured = append(captured, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	var stack strings.Builder
+	for _, frame := range captured[:min(depth, len(captured))] {
+		fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return stack.String()
+}
@@ -0,0 +1,165 @@
+package apihandler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverErrors(t *testing.T) {
+	handler := NewHandler(&Config{Recover: true})
+	_ = handler.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", res.Code)
+	}
+	select {
+	case err := <-handler.Errors():
+		if !errors.Is(err, ErrHandlerPanic) {
+			t.Fatalf("expected ErrHandlerPanic, got %s", err)
+		}
+		if !strings.Contains(err.Error(), "/panic") || !strings.Contains(err.Error(), "something went wrong") {
+			t.Fatalf("expected path and panic value in error, got %s", err)
+		}
+	default:
+		t.Fatal("expected error in channel, got nothing")
+	}
+
+	// a slow consumer must not block the requests
+	for i := 0; i < errorsBufferSize+1; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}
+	if len(handler.Errors()) != errorsBufferSize {
+		t.Fatalf("expected %d buffered errors, got %d", errorsBufferSize, len(handler.Errors()))
+	}
+}
+
+func TestRecoverStarted(t *testing.T) {
+	handler := NewHandler(&Config{Recover: true})
+	_ = handler.Get("/partial", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("something went wrong")
+	})
+	_ = handler.Get("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	// the started responses are not modified, but the panic is reported
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	if res.Code != http.StatusOK || res.Body.String() != "partial" {
+		t.Fatalf("expected 200 with 'partial', got %d '%s'", res.Code, res.Body.String())
+	}
+	if len(handler.Errors()) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(handler.Errors()))
+	}
+	<-handler.Errors()
+
+	// the aborted handlers are propagated without reporting them
+	func() {
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Fatalf("expected http.ErrAbortHandler, got %v", rec)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+		t.Fatal("expected the panic to be propagated")
+	}()
+	if len(handler.Errors()) != 0 {
+		t.Fatalf("expected no errors, got %d", len(handler.Errors()))
+	}
+}
+
+func TestRecoverHandler(t *testing.T) {
+	var recovered any
+	handler := NewHandler(&Config{
+		RecoverHandler: func(w http.ResponseWriter, r *http.Request, rec any) {
+			recovered = rec
+			http.Error(w, "custom", http.StatusServiceUnavailable)
+		},
+	})
+	_ = handler.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", res.Code)
+	}
+	if recovered != "something went wrong" {
+		t.Fatalf("expected 'something went wrong', got '%v'", recovered)
+	}
+	select {
+	case err := <-handler.Errors():
+		if !strings.Contains(err.Error(), "goroutine") {
+			t.Fatalf("expected stack trace in error, got %s", err)
+		}
+	default:
+		t.Fatal("expected error in channel, got nothing")
+	}
+}
+
+func TestPanicStackDepth(t *testing.T) {
+	handler := NewHandler(&Config{Recover: true, PanicStackDepth: 2})
+	_ = handler.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	select {
+	case err := <-handler.Errors():
+		_, stack, _ := strings.Cut(err.Error(), "\n")
+		if frames := strings.Count(stack, "\n\t"); frames != 2 {
+			t.Fatalf("expected 2 frames, got %d: %s", frames, stack)
+		}
+		if !strings.HasPrefix(stack, "github.com/lucasmenendez/apihandler.TestPanicStackDepth") {
+			t.Fatalf("expected the panicking function first in the stack, got %s", stack)
+		}
+		if strings.Contains(stack, "runtime.") {
+			t.Fatalf("expected no runtime frames, got %s", stack)
+		}
+	default:
+		t.Fatal("expected error in channel, got nothing")
+	}
+}
+
+func TestError(t *testing.T) {
+	handler := NewHandler(&Config{CORS: true, StrictPreflight: true})
+	reported := errors.New("something went wrong")
+	_ = handler.Get(testPath, func(w http.ResponseWriter, r *http.Request) {
+		handler.Error(nil)
+		handler.Error(reported)
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	select {
+	case err := <-handler.Errors():
+		if !errors.Is(err, reported) {
+			t.Fatalf("expected reported error, got %s", err)
+		}
+	default:
+		t.Fatal("expected error in channel, got nothing")
+	}
+	if len(handler.Errors()) != 0 {
+		t.Fatalf("expected no more errors, got %d", len(handler.Errors()))
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, testURI, nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	select {
+	case err := <-handler.Errors():
+		if !errors.Is(err, ErrPreflightRejected) {
+			t.Fatalf("expected ErrPreflightRejected, got %s", err)
+		}
+	default:
+		t.Fatal("expected error in channel, got nothing")
+	}
+}
@@ -0,0 +1,77 @@
+package apihandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RouteConfig struct contains the serializable definition of a route: its
+// method, its path and the name of its handler, assigned using
+// WithHandlerName.
+type RouteConfig struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// ExportConfig method returns the JSON encoded list of the routes registered
+// in the Handler, in registration order, as a list of RouteConfig. The
+// implicit HEAD routes and the exact routes are not included, and every other
+// route must have a handler name assigned using WithHandlerName, otherwise an
+// error wrapping ErrUnknownHandler is returned with the unnamed routes.
+func (m *Handler) ExportConfig() ([]byte, error) {
+	m.mtx.RLock()
+	routes := make([]*route, 0, len(m.routes))
+	for _, r := range m.routes {
+		if !r.implicit {
+			routes = append(routes, r)
+		}
+	}
+	m.mtx.RUnlock()
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].seq < routes[j].seq
+	})
+
+	config := make([]RouteConfig, 0, len(routes))
+	unnamed := []string{}
+	for _, r := range routes {
+		if r.name == "" {
+			unnamed = append(unnamed, fmt.Sprintf("[%s] %s", r.method, r.path))
+			continue
+		}
+		config = append(config, RouteConfig{Method: r.method, Path: r.path, Handler: r.name})
+	}
+	if len(unnamed) > 0 {
+		return nil, fmt.Errorf("%w: routes without handler name: %s", ErrUnknownHandler, strings.Join(unnamed, ", "))
+	}
+	return json.Marshal(config)
+}
+
+// ImportConfig method registers the routes of the provided JSON encoded list
+// of RouteConfig, as the one returned by `Handler.ExportConfig`, using the
+// handlers of the provided registry by their name. The handler name is also
+// assigned to the registered routes, so they can be exported again. If the
+// data can not be decoded or any handler name is not found in the registry, no
+// route is registered and an error (wrapping ErrUnknownHandler for the unknown
+// names) is returned. If a route can not be registered, the routes before it
+// remain registered.
+func (m *Handler) ImportConfig(data []byte, registry map[string]http.HandlerFunc) error {
+	config := []RouteConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("error decoding routes config: %w", err)
+	}
+	for _, rc := range config {
+		if _, ok := registry[rc.Handler]; !ok {
+			return fmt.Errorf("%w: '%s' for route [%s] %s", ErrUnknownHandler, rc.Handler, rc.Method, rc.Path)
+		}
+	}
+	for _, rc := range config {
+		if err := m.HandleFunc(rc.Method, rc.Path, registry[rc.Handler], WithHandlerName(rc.Handler)); err != nil {
+			return fmt.Errorf("error importing route [%s] %s: %w", rc.Method, rc.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package apihandler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExportImportConfig(t *testing.T) {
+	writer := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body + r.Header.Get("id")))
+		}
+	}
+	registry := map[string]http.HandlerFunc{
+		"listUsers":  writer("list"),
+		"getUser":    writer("get "),
+		"createUser": writer("create"),
+	}
+
+	handler := NewHandler(nil)
+	_ = handler.Get("/users", registry["listUsers"], WithHandlerName("listUsers"))
+	_ = handler.Get("/users/{id}", registry["getUser"], WithHandlerName("getUser"))
+	_ = handler.Post("/users", registry["createUser"], WithHandlerName("createUser"))
+	data, err := handler.ExportConfig()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := `[{"method":"GET","path":"/users","handler":"listUsers"},{"method":"GET","path":"/users/{id}","handler":"getUser"},{"method":"POST","path":"/users","handler":"createUser"}]`
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+
+	imported := NewHandler(nil)
+	if err := imported.ImportConfig(data, registry); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	tests := []struct {
+		method, uri, body string
+	}{
+		{http.MethodGet, "/users", "list"},
+		{http.MethodGet, "/users/42", "get 42"},
+		{http.MethodPost, "/users", "create"},
+		{http.MethodHead, "/users", ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		imported.ServeHTTP(res, httptest.NewRequest(test.method, test.uri, nil))
+		if res.Code != http.StatusOK || res.Body.String() != test.body {
+			t.Fatalf("expected 200 %q for %s %s, got %d %q", test.body, test.method, test.uri, res.Code, res.Body.String())
+		}
+	}
+	// the imported routes can be exported again
+	if again, err := imported.ExportConfig(); err != nil || string(again) != expected {
+		t.Fatalf("expected %s, got %s (%v)", expected, again, err)
+	}
+}
+
+func TestExportImportConfigErrors(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/unnamed", testHandler)
+	if _, err := handler.ExportConfig(); !errors.Is(err, ErrUnknownHandler) {
+		t.Fatalf("expected ErrUnknownHandler, got %v", err)
+	}
+
+	handler = NewHandler(nil)
+	data := []byte(`[{"method":"GET","path":"/a","handler":"a"},{"method":"GET","path":"/b","handler":"b"}]`)
+	err := handler.ImportConfig(data, map[string]http.HandlerFunc{"a": testHandler})
+	if !errors.Is(err, ErrUnknownHandler) {
+		t.Fatalf("expected ErrUnknownHandler, got %v", err)
+	}
+	if handler.Len() != 0 {
+		t.Fatalf("expected no route imported, got %d", handler.Len())
+	}
+	if err := handler.ImportConfig([]byte("{"), nil); err == nil {
+		t.Fatalf("expected decoding error")
+	}
+}
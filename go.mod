@@ -1,5 +1,5 @@
 module github.com/lucasmenendez/apihandler
 
-go 1.20
+go 1.21
 
 require golang.org/x/time v0.5.0
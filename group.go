@@ -0,0 +1,94 @@
+package apihandler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Group struct allows to register routes sharing a path prefix and a list of
+// middlewares. The routes registered through a group are registered in the
+// Handler with the full path, as if they were registered directly.
+type Group struct {
+	handler *Handler
+	prefix  string
+	mws     []Middleware
+}
+
+// Group method returns a new Group of routes of the current Handler with the
+// provided path prefix.
+func (m *Handler) Group(prefix string) *Group {
+	return &Group{
+		handler: m,
+		prefix:  strings.TrimSuffix(prefix, uriSeparator),
+	}
+}
+
+// Group method returns a new Group nested in the current one, whose prefix is
+// the concatenation of the current prefix and the provided one. The nested
+// group inherits the middlewares of the current group.
+func (g *Group) Group(prefix string) *Group {
+	return &Group{
+		handler: g.handler,
+		prefix:  g.prefix + strings.TrimSuffix(prefix, uriSeparator),
+		mws:     append([]Middleware{}, g.mws...),
+	}
+}
+
+// Use method appends the provided middlewares to the group, which wrap the
+// handlers of the routes registered through the group after calling it. The
+// first middleware is the outermost layer.
+func (g *Group) Use(mws ...Middleware) {
+	g.mws = append(g.mws, mws...)
+}
+
+// HandleFunc method wraps `Handler.HandleFunc` prepending the group prefix to
+// the provided path and wrapping the provided handler with the group
+// middlewares.
+func (g *Group) HandleFunc(method, path string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.handler.HandleFunc(method, g.prefix+path, chain(h, g.mws...), opts...)
+}
+
+// Get method wraps `Group.HandleFunc` for HTTP method 'GET'.
+func (g *Group) Get(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodGet, p, h, opts...)
+}
+
+// Head method wraps `Group.HandleFunc` for HTTP method 'HEAD'.
+func (g *Group) Head(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodHead, p, h, opts...)
+}
+
+// Post method wraps `Group.HandleFunc` for HTTP method 'POST'.
+func (g *Group) Post(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodPost, p, h, opts...)
+}
+
+// Put method wraps `Group.HandleFunc` for HTTP method 'PUT'.
+func (g *Group) Put(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodPut, p, h, opts...)
+}
+
+// Patch method wraps `Group.HandleFunc` for HTTP method 'PATCH'.
+func (g *Group) Patch(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodPatch, p, h, opts...)
+}
+
+// Delete method wraps `Group.HandleFunc` for HTTP method 'DELETE'.
+func (g *Group) Delete(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodDelete, p, h, opts...)
+}
+
+// Connect method wraps `Group.HandleFunc` for HTTP method 'CONNECT'.
+func (g *Group) Connect(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodConnect, p, h, opts...)
+}
+
+// Options method wraps `Group.HandleFunc` for HTTP method 'OPTIONS'.
+func (g *Group) Options(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodOptions, p, h, opts...)
+}
+
+// Trace method wraps `Group.HandleFunc` for HTTP method 'TRACE'.
+func (g *Group) Trace(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return g.HandleFunc(http.MethodTrace, p, h, opts...)
+}
@@ -0,0 +1,58 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	handler := NewHandler(nil)
+	calls := []string{}
+	api := handler.Group("/api/v1/")
+	api.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "api")
+			next(w, r)
+		}
+	})
+	users := api.Group("/users")
+	users.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, "users")
+			next(w, r)
+		}
+	})
+	if err := users.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "id")))
+	}); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if err := api.Get("/status", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	if r, exist := handler.find(http.MethodGet, "/api/v1/users/123"); !exist || r.path != "/api/v1/users/{id}" {
+		t.Fatalf("expected route /api/v1/users/{id}")
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/users/123", nil))
+	if body := res.Body.String(); body != "123" {
+		t.Fatalf("expected '123', got '%s'", body)
+	}
+	if len(calls) != 2 || calls[0] != "api" || calls[1] != "users" {
+		t.Fatalf("expected [api users], got %v", calls)
+	}
+
+	// the middlewares of nested groups are not applied to the parent group
+	calls = []string{}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if len(calls) != 1 || calls[0] != "api" {
+		t.Fatalf("expected [api], got %v", calls)
+	}
+}
@@ -0,0 +1,198 @@
+package apihandler
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compressionKey is the context key to store the compression state of the
+// current request.
+const compressionKey contextKey = "compression"
+
+// compressionState struct contains if the response compression has been
+// disabled for the current request. It is shared through the request context
+// between the GzipMiddleware and the Handler, which can disable the
+// compression once the route is matched, before anything is written.
+type compressionState struct {
+	disabled bool
+}
+
+// disableCompression function disables the response compression for the
+// request of the provided context. If the GzipMiddleware has already stored a
+// compression state in the context, it is updated, otherwise a disabled state
+// is stored in a new context that is returned.
+func disableCompression(ctx context.Context) context.Context {
+	if state, ok := ctx.Value(compressionKey).(*compressionState); ok {
+		state.disabled = true
+		return ctx
+	}
+	return context.WithValue(ctx, compressionKey, &compressionState{disabled: true})
+}
+
+// gzipResponseWriter struct wraps an http.ResponseWriter to compress the data
+// written with gzip. It decides if the response must be compressed when the
+// headers are written, so the compression can be disabled before that, but
+// the status of the compressed responses is kept pending until the body is
+// written, so the responses without body are sent uncompressed.
+type gzipResponseWriter struct {
+	*responseWriter
+	level   int
+	head    bool
+	state   *compressionState
+	gz      *gzip.Writer
+	pending int
+}
+
+// compressible method returns if the response with the provided status code
+// must be compressed: the compression is not disabled, the handler did not
+// already encode the response, and the response can have a body, which is
+// not the case of the HEAD requests and the 1xx, 204 and 304 statuses.
+func (w *gzipResponseWriter) compressible(status int) bool {
+	if w.state.disabled || w.head || w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// WriteHeader method writes the provided status code, or keeps it pending
+// until the body is written if the response must be compressed. The
+// informational (1xx) statuses are sent as they are.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.written() || w.pending != 0 {
+		return
+	}
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if !w.compressible(status) {
+		w.responseWriter.WriteHeader(status)
+		return
+	}
+	w.pending = status
+}
+
+// compress method sets the compression headers, creates the gzip writer and
+// writes the pending status code, if any.
+func (w *gzipResponseWriter) compress() {
+	if w.pending == 0 {
+		return
+	}
+	status := w.pending
+	w.pending = 0
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Del("Content-Length")
+	gz, err := gzip.NewWriterLevel(w.ResponseWriter, w.level)
+	if err != nil {
+		gz = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.gz = gz
+	w.responseWriter.WriteHeader(status)
+}
+
+// Write method writes the provided data into the gzip writer, or directly to
+// the wrapped writer if the response is not compressed.
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if w.status == 0 && w.pending == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.pending != 0 {
+		if len(data) == 0 {
+			return 0, nil
+		}
+		w.compress()
+	}
+	if w.gz == nil {
+		return w.responseWriter.Write(data)
+	}
+	return w.gz.Write(data)
+}
+
+// Flush method starts the compressed response if its status is pending, and
+// flushes the data buffered by the gzip writer, if it was created, and the
+// wrapped writer.
+func (w *gzipResponseWriter) Flush() {
+	w.compress()
+	if w.gz != nil && !w.hijacked {
+		_ = w.gz.Flush()
+	}
+	w.responseWriter.Flush()
+}
+
+// Close method writes the pending status code without compression if
+// nothing was written, or flushes and closes the gzip writer if it was
+// created.
+func (w *gzipResponseWriter) Close() error {
+	if w.pending != 0 {
+		status := w.pending
+		w.pending = 0
+		w.responseWriter.WriteHeader(status)
+		return nil
+	}
+	if w.gz == nil || w.hijacked {
+		return nil
+	}
+	return w.gz.Close()
+}
+
+// acceptsGzip function returns if the provided request accepts gzip encoded
+// responses according to its 'Accept-Encoding' header. The gzip encoding is
+// not accepted if it is listed with a zero quality value ('gzip;q=0').
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(strings.TrimSpace(key), "q") {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// GzipMiddleware function returns a Middleware that compresses the responses
+// using gzip with the provided compression level, if the client accepts it,
+// setting the 'Content-Encoding' and 'Vary' headers. Responses whose
+// 'Content-Encoding' was already set by the handler are not compressed again,
+// neither the responses without body (HEAD requests, 1xx, 204 and 304
+// statuses or empty bodies), and the gzip writer is flushed and closed once
+// the handler returns. Routes
+// registered with WithNoCompression option are not compressed. To
+// apply it to every route, wrap the Handler with it:
+//
+//	http.ListenAndServe(":8080", GzipMiddleware(gzip.DefaultCompression)(handler.ServeHTTP))
+func GzipMiddleware(level int) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// skip if the client does not accept gzip or if there is already
+			// a compression state (disabled or already compressing)
+			if !acceptsGzip(r) || r.Context().Value(compressionKey) != nil {
+				next(w, r)
+				return
+			}
+			state := &compressionState{}
+			gw := &gzipResponseWriter{
+				responseWriter: &responseWriter{ResponseWriter: w},
+				level:          level,
+				head:           r.Method == http.MethodHead,
+				state:          state,
+			}
+			defer gw.Close()
+			next(gw, r.WithContext(context.WithValue(r.Context(), compressionKey, state)))
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package apihandler
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithNoCompression(t *testing.T) {
+	body := strings.Repeat("compressible content ", 100)
+	writeBody := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}
+	handler := NewHandler(nil)
+	_ = handler.Get("/text", writeBody)
+	_ = handler.Get("/image", writeBody, WithNoCompression())
+	server := GzipMiddleware(gzip.BestSpeed)(handler.ServeHTTP)
+
+	req := httptest.NewRequest(http.MethodGet, "/text", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res := httptest.NewRecorder()
+	server(res, req)
+	if encoding := res.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected 'gzip', got '%s'", encoding)
+	}
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if string(decoded) != body {
+		t.Fatalf("expected original body, got '%s'", string(decoded))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/image", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res = httptest.NewRecorder()
+	server(res, req)
+	if encoding := res.Header().Get("Content-Encoding"); encoding != "" {
+		t.Fatalf("expected no encoding, got '%s'", encoding)
+	}
+	if res.Body.String() != body {
+		t.Fatalf("expected uncompressed body, got '%s'", res.Body.String())
+	}
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	body := strings.Repeat(`{"key":"value"},`, 100)
+	server := GzipMiddleware(gzip.DefaultCompression)(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, testURI, nil)
+	req.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	res := httptest.NewRecorder()
+	server(res, req)
+	if encoding := res.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected 'gzip', got '%s'", encoding)
+	}
+	if vary := res.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Fatalf("expected 'Accept-Encoding', got '%s'", vary)
+	}
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if string(decoded) != body {
+		t.Fatalf("expected original body, got '%s'", string(decoded))
+	}
+
+	for _, accept := range []string{"", "deflate", "gzip;q=0"} {
+		req = httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.Header.Set("Accept-Encoding", accept)
+		res = httptest.NewRecorder()
+		server(res, req)
+		if encoding := res.Header().Get("Content-Encoding"); encoding != "" {
+			t.Fatalf("expected no encoding for '%s', got '%s'", accept, encoding)
+		}
+		if res.Body.String() != body {
+			t.Fatalf("expected plain body for '%s', got '%s'", accept, res.Body.String())
+		}
+	}
+}
+
+func TestGzipMiddlewareEncoded(t *testing.T) {
+	server := GzipMiddleware(gzip.DefaultCompression)(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		_, _ = w.Write([]byte("encoded"))
+	})
+	req := httptest.NewRequest(http.MethodGet, testURI, nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	res := httptest.NewRecorder()
+	server(res, req)
+	if encoding := res.Header().Get("Content-Encoding"); encoding != "br" {
+		t.Fatalf("expected 'br', got '%s'", encoding)
+	}
+	if res.Body.String() != "encoded" {
+		t.Fatalf("expected 'encoded', got '%s'", res.Body.String())
+	}
+}
+
+func TestGzipMiddlewareNoBody(t *testing.T) {
+	tests := []struct {
+		method string
+		status int
+		body   string
+	}{
+		{http.MethodGet, http.StatusNoContent, ""},
+		{http.MethodGet, http.StatusNotModified, ""},
+		{http.MethodGet, http.StatusOK, ""},
+		{http.MethodHead, http.StatusOK, "body"},
+	}
+	for _, test := range tests {
+		server := GzipMiddleware(gzip.DefaultCompression)(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			if test.body != "" {
+				_, _ = w.Write([]byte(test.body))
+			}
+		})
+		req := httptest.NewRequest(test.method, testURI, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res := httptest.NewRecorder()
+		server(res, req)
+		if res.Code != test.status {
+			t.Fatalf("expected %d for %s, got %d", test.status, test.method, res.Code)
+		}
+		if encoding := res.Header().Get("Content-Encoding"); encoding != "" {
+			t.Fatalf("expected no encoding for %s %d, got '%s'", test.method, test.status, encoding)
+		}
+		if test.method == http.MethodGet && res.Body.Len() != 0 {
+			t.Fatalf("expected no body for %d, got %d bytes", test.status, res.Body.Len())
+		}
+	}
+}
@@ -4,13 +4,21 @@
 package apihandler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"net/url"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
-
-	"golang.org/x/time/rate"
+	"time"
 )
 
 // uriSeparator contains a string with the backslash character to split the
@@ -18,14 +26,50 @@ import (
 const uriSeparator = "/"
 
 // argsToRgxSub constant contains the regex pattern to match a named argument
-// in a request URI, includes the interpolation of the name of the argument.
-const argsToRgxSub = "(?P<$arg_name>.+)"
+// in a request URI, includes the interpolation of the name of the argument
+// and its constraint regex.
+const argsToRgxSub = "(?P<%s>%s)"
+
+// defaultArgRgx constant contains the regex that the named arguments without
+// constraint (e.g. '{id}' instead of '{id:[0-9]+}') must match.
+const defaultArgRgx = ".+"
+
+// prefixArgRgx constant contains the regex that the named arguments without
+// constraint of the prefix routes and of the routes ending with an optional or
+// a catch-all argument must match, a single URI segment, so the previous
+// arguments do not capture the segments of the last one.
+const prefixArgRgx = "[^/]+"
+
+// prefixEndRgx constant contains the regex that matches the end of the path
+// of a prefix route in a request URI, a separator or the end of the URI.
+const prefixEndRgx = "(?:/|$)"
+
+// optionalArgToRgxSub constant contains the regex pattern to match an optional
+// named argument at the end of a request URI, including its separator, which
+// includes the interpolation of the name of the argument.
+const optionalArgToRgxSub = "(?:/(?P<$arg_name>[^/]+))?"
+
+// optionalArgToRgx variable is a regex that allows to detect an optional named
+// argument (e.g. '{page?}') as the last segment of a route path.
+var optionalArgToRgx = regexp.MustCompile(`/\{(?P<arg_name>[^{}/]+)\?\}$`)
+
+// catchAllArgToRgxSub constant contains the regex pattern to match a catch-all
+// named argument at the end of a request URI, including its separator, which
+// includes the interpolation of the name of the argument.
+const catchAllArgToRgxSub = "/(?P<$arg_name>.+)"
+
+// catchAllArgToRgx variable is a regex that allows to detect a catch-all named
+// argument (e.g. '{path...}') as the last segment of a route path.
+var catchAllArgToRgx = regexp.MustCompile(`/\{(?P<arg_name>[^{}/:]+)\.\.\.\}$`)
 
 // argsToRgx variable is a regex that allows to detect named arguments from a
-// route path, helping to build a regex to match requests URIs with the route
-// supporting named args.
+// route path, for example, to count them.
 var argsToRgx = regexp.MustCompile(`(?U)\{(?P<arg_name>.+)\}`)
 
+// argGroupRgx variable is a regex that matches the opening of the named
+// groups of a route regex, to remove the names of its arguments.
+var argGroupRgx = regexp.MustCompile(`\(\?P<[^>]+>`)
+
 // supportedMethods variable contains the list of HTTP suppoted methods
 var supportedMethods = []string{
 	http.MethodGet,
@@ -39,6 +83,16 @@ var supportedMethods = []string{
 	http.MethodTrace,
 }
 
+// isSupportedMethod function returns if the provided HTTP method is supported.
+func isSupportedMethod(method string) bool {
+	for _, supported := range supportedMethods {
+		if supported == method {
+			return true
+		}
+	}
+	return false
+}
+
 // route struct contains the parameters of a valid route, which contains the
 // method, the path, a regex to match request URIs with paths that use named
 // arguments, and the route handler.
@@ -47,89 +101,799 @@ type route struct {
 	path    string
 	rgx     *regexp.Regexp
 	handler func(http.ResponseWriter, *http.Request)
+	// noCompression disables the response compression for the route
+	noCompression bool
+	// suffixArg and suffixes define the argument to split and the names of
+	// its dot-separated suffixes
+	suffixArg string
+	suffixes  []string
+	// exact routes are matched comparing the request URI with the path
+	exact bool
+	// disabled routes are skipped when looking for a matching route
+	disabled bool
+	// implicit routes are the HEAD routes registered automatically for the
+	// GET ones, which are overwritten by the explicitly registered ones
+	implicit bool
+	// normalizers contains the functions to normalize the decoded arguments
+	normalizers map[string]func(string) string
+	// defaults contains the values of the arguments used when they are not
+	// captured from the request URI
+	defaults map[string]string
+	// optional routes ends with an optional argument, so they also match the
+	// request URIs without their last segment
+	optional bool
+	// catchAll routes ends with a catch-all argument, so they match the
+	// request URIs with any number of segments after their prefix
+	catchAll bool
+	// prefix routes match the request URIs that start with their path
+	prefix bool
+	// tags contains arbitrary labels of the route, exposed to the handlers
+	tags []string
+	// logLevel defines the level of the access logs of the route
+	logLevel slog.Level
+	// key identifies the route when its handler is shared by other routes
+	key string
+	// notAllowed handles the requests to the route path with other methods
+	notAllowed http.HandlerFunc
+	// readDeadline and writeDeadline define the deadlines to read the request
+	// body and to write the response of the route
+	readDeadline  time.Duration
+	writeDeadline time.Duration
+	// static routes have no named arguments nor regex metacharacters, so
+	// they are matched comparing the request URI with the path
+	static bool
+	// seq is the registration order of the route, used to give precedence
+	// to the routes registered first when more than one route matches
+	seq uint64
+	// depth is the number of separators of the route path, compared with
+	// the number of segments of the request URIs before running the regex
+	depth int
+	// maxBody limits the size of the request body of the route, replacing
+	// the limit of the Handler
+	maxBody int64
+	// name references the handler of the route in the registries used to
+	// export and import the routes table
+	name string
+	// routeName identifies the route to build its URLs using `Handler.URL`
+	routeName string
+}
+
+// RouteOption type defines a function that sets an optional parameter of a
+// route when it is registered.
+type RouteOption func(*route)
+
+// WithNoCompression function returns a RouteOption that disables the response
+// compression of the GzipMiddleware for the route, which is useful for routes
+// that serve already compressed content like images or videos.
+func WithNoCompression() RouteOption {
+	return func(r *route) {
+		r.noCompression = true
+	}
+}
+
+// WithSuffixes function returns a RouteOption that splits the dot-separated
+// suffixes of the provided argument into new arguments with the provided
+// names, in the order in which they appear in the path. For example, with
+// `WithSuffixes("slug", "lang", "format")`, the URI '/page.en.json' results in
+// slug 'page', lang 'en' and format 'json'. The suffixes are peeled from the
+// right, so if there are less suffixes than names, the last names are filled
+// first ('/page.json' results in format 'json' and an empty lang), and the
+// argument keeps any remaining dot ('/my.page.en.json' results in slug
+// 'my.page'). As a consequence, an argument that contains dots is ambiguous if
+// it is not followed by every suffix ('/my.page' results in slug 'my' and
+// format 'page').
+func WithSuffixes(arg string, names ...string) RouteOption {
+	return func(r *route) {
+		r.suffixArg = arg
+		r.suffixes = names
+	}
+}
+
+// WithParamNormalizer function returns a RouteOption that applies the provided
+// function to the value of the named argument provided when it is decoded from
+// a request URI, for example, to trim or lowercase it, before it is exposed to
+// the route handler.
+func WithParamNormalizer(name string, fn func(string) string) RouteOption {
+	return func(r *route) {
+		if r.normalizers == nil {
+			r.normalizers = map[string]func(string) string{}
+		}
+		r.normalizers[name] = fn
+	}
+}
+
+// WithParamDefault function returns a RouteOption that sets the provided value
+// as the value of the named argument provided when it is not captured from the
+// request URI, for example, when an optional argument ('{page?}') is absent.
+func WithParamDefault(name, value string) RouteOption {
+	return func(r *route) {
+		if r.defaults == nil {
+			r.defaults = map[string]string{}
+		}
+		r.defaults[name] = value
+	}
+}
+
+// WithTags function returns a RouteOption that assigns the provided labels to
+// the route, which are exposed to handlers and middlewares through the
+// RouteContext of the request, for example, to group routes in logs.
+func WithTags(tags ...string) RouteOption {
+	return func(r *route) {
+		r.tags = append(r.tags, tags...)
+	}
+}
+
+// WithLogLevel function returns a RouteOption that sets the level of the
+// access logs of the route written by the Logging middleware, which reads it
+// from the RouteContext of the request. Use LogLevelSilent to skip the access
+// logs of the route. By default, the routes are logged at slog.LevelInfo.
+func WithLogLevel(level slog.Level) RouteOption {
+	return func(r *route) {
+		r.logLevel = level
+	}
+}
+
+// WithPrefixMatch function returns a RouteOption that makes the route match
+// every request URI that starts with its path (followed by a separator or the
+// end of the URI), instead of the full URI, for example, to mount a
+// sub-handler. The named arguments of the path capture a single segment.
+func WithPrefixMatch() RouteOption {
+	return func(r *route) {
+		r.prefix = true
+	}
+}
+
+// WithMethodNotAllowed function returns a RouteOption that assigns the
+// provided handler to respond to the requests whose path matches with the
+// route path but whose method is not registered for it, instead of the
+// fallbacks and the default 405 HTTP error. The handler is shared by every
+// route with the same path, so it only needs to be assigned to one of them.
+func WithMethodNotAllowed(h http.HandlerFunc) RouteOption {
+	return func(r *route) {
+		r.notAllowed = h
+	}
+}
+
+// WithRouteKey function returns a RouteOption that assigns the provided key
+// to the route, which is exposed to its handler through RouteKey. It allows to
+// share a single handler between many routes distinguishing them by their
+// key, instead of creating a closure per route.
+func WithRouteKey(key string) RouteOption {
+	return func(r *route) {
+		r.key = key
+	}
+}
+
+// WithHandlerName function returns a RouteOption that assigns the provided
+// name to the handler of the route, which is used to reference it when the
+// routes table is exported using `Handler.ExportConfig` and imported again
+// using `Handler.ImportConfig`.
+func WithHandlerName(name string) RouteOption {
+	return func(r *route) {
+		r.name = name
+	}
+}
+
+// WithRouteName function returns a RouteOption that assigns the provided name
+// to the route, which is used to build the URLs of the route using
+// `Handler.URL` instead of concatenating them by hand.
+func WithRouteName(name string) RouteOption {
+	return func(r *route) {
+		r.routeName = name
+	}
+}
+
+// WithDeadlines function returns a RouteOption that sets the provided read and
+// write deadlines on the connection of the requests served by the route,
+// relative to the time when the route handler starts. Zero durations are
+// ignored. The deadlines are set using `http.ResponseController`, so if the
+// underlying response writer does not support them, they are not applied.
+func WithDeadlines(read, write time.Duration) RouteOption {
+	return func(r *route) {
+		r.readDeadline = read
+		r.writeDeadline = write
+	}
+}
+
+// WithMaxBody function returns a RouteOption that limits the size of the
+// request body of the route to the provided number of bytes, replacing the
+// limit of Config.MaxBodyBytes for the route.
+func WithMaxBody(limit int64) RouteOption {
+	return func(r *route) {
+		r.maxBody = limit
+	}
+}
+
+// bodyLimit method returns the request body limit of the route, or the
+// provided default one if the route does not define it.
+func (r *route) bodyLimit(defaultLimit int64) int64 {
+	if r.maxBody > 0 {
+		return r.maxBody
+	}
+	return defaultLimit
+}
+
+// setDeadlines function sets the read and write deadlines of the route, if
+// they are defined, on the connection of the provided response writer. The
+// deadlines are ignored if the response writer does not support them.
+func (r *route) setDeadlines(res http.ResponseWriter) {
+	if r.readDeadline <= 0 && r.writeDeadline <= 0 {
+		return
+	}
+	rc := http.NewResponseController(res)
+	now := time.Now()
+	if r.readDeadline > 0 {
+		_ = rc.SetReadDeadline(now.Add(r.readDeadline))
+	}
+	if r.writeDeadline > 0 {
+		_ = rc.SetWriteDeadline(now.Add(r.writeDeadline))
+	}
 }
 
 // parse function transforms the provided path into a regex to match with
 // the URI of incoming requests. The resulting regex will be stored into current
-// route and will be used to match named arguments from a request URI.
+// route and will be used to match named arguments from a request URI. Every
+// regex is anchored to the start and the end of the URI. If the path ends with
+// an optional argument, its segment is optional in the regex, and if it ends
+// with a catch-all argument, it captures the rest of the URI, including its
+// separators. Prefix routes are not anchored to the end of the URI.
 func (r *route) parse() error {
-	rgx := argsToRgx.ReplaceAllString(r.path, argsToRgxSub)
-	escapedRgx := strings.ReplaceAll(rgx, "/", "\\/")
-	var err error
-	if r.rgx, err = regexp.Compile(fmt.Sprintf("%s$", escapedRgx)); err != nil {
+	path, last, end, argRgx := r.path, "", "$", defaultArgRgx
+	if r.prefix {
+		path, _ = strings.CutSuffix(path, uriSeparator)
+		end, argRgx = prefixEndRgx, prefixArgRgx
+	} else if loc := optionalArgToRgx.FindStringIndex(path); loc != nil {
+		r.optional, argRgx = true, prefixArgRgx
+		last = optionalArgToRgx.ReplaceAllString(path[loc[0]:], optionalArgToRgxSub)
+		path = path[:loc[0]]
+	} else if loc := catchAllArgToRgx.FindStringIndex(path); loc != nil {
+		r.catchAll, argRgx = true, prefixArgRgx
+		last = catchAllArgToRgx.ReplaceAllString(path[loc[0]:], catchAllArgToRgxSub)
+		path = path[:loc[0]]
+	}
+	rgx, err := expandArgs(path, argRgx)
+	if err != nil {
+		return fmt.Errorf("error parsing path: %w", err)
+	}
+	escapedRgx := strings.ReplaceAll(rgx+last, "/", "\\/")
+	if r.rgx, err = regexp.Compile("^" + escapedRgx + end); err != nil {
 		return fmt.Errorf("error parsing path: %w", err)
 	}
+	r.static = !r.prefix && isLiteralSegment(r.path)
+	r.depth = strings.Count(r.path, uriSeparator)
 	return nil
 }
 
+// pattern method returns the regex of the route without the names of its
+// arguments, so the routes that only differ in the names of their arguments
+// have the same pattern.
+func (r *route) pattern() string {
+	return argGroupRgx.ReplaceAllString(r.rgx.String(), "(")
+}
+
+// nextArg function returns the position of the braces of the first named
+// argument of the provided path, balancing the braces of its constraint to
+// find its end. It returns false if the path has no more arguments.
+func nextArg(path string) (int, int, bool) {
+	start := strings.Index(path, "{")
+	if start < 0 {
+		return 0, 0, false
+	}
+	depth := 0
+	for i := start; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth--; depth == 0 {
+				return start, i, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// expandArgs function replaces the named arguments of the provided path with
+// their regex patterns. The arguments can define a constraint regex after a
+// colon (e.g. '{id:[0-9]+}'), that is used instead of the provided default
+// one. The braces of the constraints are balanced to find the
+// end of every argument. It returns an error if a constraint is not a valid
+// regex.
+func expandArgs(path, defaultRgx string) (string, error) {
+	var rgx strings.Builder
+	for {
+		start, end, ok := nextArg(path)
+		if !ok {
+			break
+		}
+		name, constraint, found := strings.Cut(path[start+1:end], ":")
+		if !found || constraint == "" {
+			constraint = defaultRgx
+		} else if _, err := regexp.Compile(constraint); err != nil {
+			return "", fmt.Errorf("invalid constraint of argument '%s': %w", name, err)
+		}
+		rgx.WriteString(path[:start])
+		rgx.WriteString(fmt.Sprintf(argsToRgxSub, name, constraint))
+		path = path[end+1:]
+	}
+	rgx.WriteString(path)
+	return rgx.String(), nil
+}
+
 // match function returns if the requestURI provided matches with the current
 // route regex. It also checks if both arguments have the same number of
-// URI parts to ensure that is the same level of depth.
+// URI parts to ensure that is the same level of depth, or one less if the
+// route ends with an optional argument, or at least the same if it ends with
+// a catch-all argument. Exact and static routes are matched comparing the
+// request URI with the path, and prefix routes only with the anchored regex.
 func (r *route) match(requestURI string) bool {
+	if r.exact || r.static {
+		return requestURI == r.path
+	}
+	if r.prefix {
+		return r.rgx.MatchString(requestURI)
+	}
+	return r.matchDepth(requestURI) && r.rgx.MatchString(requestURI)
+}
+
+// matchDepth method returns if the number of segments of the request URI
+// provided is compatible with the depth of the route, taking into account its
+// optional and catch-all arguments. It allows to discard most of the routes
+// without running their regex.
+func (r *route) matchDepth(requestURI string) bool {
 	uri, _ := strings.CutSuffix(requestURI, uriSeparator)
 	lenURI := strings.Count(uri, uriSeparator)
-	lenRgx := strings.Count(r.rgx.String(), uriSeparator)
-	return lenURI == lenRgx && r.rgx.MatchString(requestURI)
+	if r.optional && lenURI == r.depth-1 {
+		lenURI++
+	}
+	if r.catchAll && lenURI > r.depth {
+		lenURI = r.depth
+	}
+	return lenURI == r.depth
+}
+
+// matchPath function returns the form of the path of the provided URL that the
+// routes are matched with, which is the decoded path except for the escaped
+// slashes and percent signs ('%2F' and '%25'). They are kept escaped, so they
+// do not split the segments nor form new escapes, and they are unescaped once
+// the arguments are decoded.
+func matchPath(u *url.URL) string {
+	escaped := u.EscapedPath()
+	if !strings.Contains(escaped, "%") {
+		return escaped
+	}
+	var path strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '%' && i+2 < len(escaped) {
+			code := strings.ToUpper(escaped[i+1 : i+3])
+			if code == "2F" || code == "25" {
+				path.WriteString("%" + code)
+				i += 2
+				continue
+			}
+			if b, err := strconv.ParseUint(code, 16, 8); err == nil {
+				path.WriteByte(byte(b))
+				i += 2
+				continue
+			}
+		}
+		path.WriteByte(escaped[i])
+	}
+	return path.String()
 }
 
 // decodeArgs function returns if the request URI matches with the route regex
-// provided and the named arguments that the URI could contain.
+// provided and the named arguments that the URI could contain. The request URI
+// is expected in the form returned by matchPath, so the arguments are
+// unescaped once captured and it does not match if any of them is malformed.
 func (r *route) decodeArgs(requestURI string) (map[string]string, bool) {
-	// check if matches
-	if !r.match(requestURI) {
+	// exact and static routes have no arguments to decode
+	if r.exact || r.static {
+		if requestURI != r.path {
+			return nil, false
+		}
+		return map[string]string{}, true
+	}
+	// check the depth before running the regex, which checks if matches
+	// while it finds the named arguments
+	if !r.prefix && !r.matchDepth(requestURI) {
 		return nil, false
 	}
-	// find named arguments
-	args := make(map[string]string)
 	uri, _ := strings.CutSuffix(requestURI, uriSeparator)
 	matches := r.rgx.FindStringSubmatch(uri)
 	if len(matches) < 1 {
 		return nil, false
 	}
-	for i, name := range r.rgx.SubexpNames()[0:] {
-		args[name] = matches[i]
+	args := make(map[string]string)
+	for i, name := range r.rgx.SubexpNames() {
+		// skip the whole match and the unnamed groups
+		if i == 0 || name == "" {
+			continue
+		}
+		// unescape the captured values, the request URI does not match if
+		// any of them is malformed
+		value, err := url.PathUnescape(matches[i])
+		if err != nil {
+			return nil, false
+		}
+		args[name] = value
+	}
+	r.peelSuffixes(args)
+	for name, normalize := range r.normalizers {
+		if value, ok := args[name]; ok {
+			args[name] = normalize(value)
+		}
+	}
+	for name, value := range r.defaults {
+		if args[name] == "" {
+			args[name] = value
+		}
 	}
 	return args, true
 }
 
+// peelSuffixes function splits the dot-separated suffixes of the argument
+// defined using the WithSuffixes option, storing every suffix found as a new
+// argument and the rest as the value of the original argument.
+func (r *route) peelSuffixes(args map[string]string) {
+	if len(r.suffixes) == 0 {
+		return
+	}
+	value, ok := args[r.suffixArg]
+	if !ok {
+		return
+	}
+	for i := len(r.suffixes) - 1; i >= 0; i-- {
+		args[r.suffixes[i]] = ""
+		if idx := strings.LastIndex(value, "."); idx > 0 {
+			args[r.suffixes[i]] = value[idx+1:]
+			value = value[:idx]
+		}
+	}
+	args[r.suffixArg] = value
+}
+
+// argsIndex function returns the byte offsets of the named arguments captured
+// from the request URI provided, if it matches with the route regex. Every
+// argument name is mapped to its start and end offsets in the request URI.
+func (r *route) argsIndex(requestURI string) (map[string][2]int, bool) {
+	if !r.match(requestURI) {
+		return nil, false
+	}
+	if r.exact {
+		return map[string][2]int{}, true
+	}
+	uri, _ := strings.CutSuffix(requestURI, uriSeparator)
+	matches := r.rgx.FindStringSubmatchIndex(uri)
+	if len(matches) < 2 {
+		return nil, false
+	}
+	offsets := make(map[string][2]int)
+	for i, name := range r.rgx.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		offsets[name] = [2]int{matches[2*i], matches[2*i+1]}
+	}
+	return offsets, true
+}
+
+// RouteInfo struct contains the public information of a registered route, its
+// HTTP method and its path.
+type RouteInfo struct {
+	Method string
+	Path   string
+}
+
+// RateLimitConfig struct defines the rate (requests per second) and the burst
+// size (Limit) of the rate limiter of every client. The TTL defines how long
+// the default in-memory LimiterStore keeps the rate limiter of an idle client
+// before removing it, zero keeps them forever. The idle rate limiters are
+// removed while serving the requests, without background goroutines, so the
+// Handler does not need to be closed on shutdown. The Key function returns the
+// key to identify the client of a request, for example, the user id stored in
+// its context by an authentication middleware; if it is not defined or it
+// returns false, the client IP returned by ClientIP is used.
 type RateLimitConfig struct {
 	Rate  float64
 	Limit int
+	TTL   time.Duration
+	Key   func(*http.Request) (string, bool)
 }
 
 type Config struct {
 	CORS bool
+	// AllowedOrigins contains the origins allowed by CORS. The request origin
+	// is echoed only if it is listed, otherwise the
+	// 'Access-Control-Allow-Origin' header is omitted. If it is empty, every
+	// origin is allowed using the '*' wildcard.
+	AllowedOrigins []string
+	// AllowCredentials makes the CORS responses to the AllowedOrigins allow
+	// credentials (cookies and authorization headers). It is ignored if no
+	// AllowedOrigins are defined, since any website could read the
+	// credentialed responses otherwise.
+	AllowCredentials bool
+	// AllowedHeaders contains the request headers allowed by CORS. If it is
+	// empty, every header is allowed.
+	AllowedHeaders []string
+	// ExposedHeaders contains the response headers exposed by CORS to the
+	// browser scripts.
+	ExposedHeaders []string
+	// CORSMaxAge defines how long the browsers can cache the CORS preflight
+	// responses, sent in seconds in the 'Access-Control-Max-Age' header. Zero
+	// omits the header.
+	CORSMaxAge time.Duration
+	// StrictPreflight makes CORS preflight requests fail with 403 if the
+	// method requested in the 'Access-Control-Request-Method' header is not
+	// registered for the requested path.
+	StrictPreflight bool
+	// MaxPathSegments limits the number of segments that the path of an
+	// incoming request can have, rejecting longer ones with a 400 HTTP error.
+	// Zero disables the limit.
+	MaxPathSegments int
+	// DecompressRequests enables the transparent decompression of the request
+	// bodies encoded with gzip or deflate.
+	DecompressRequests bool
+	// MaxDecompressedBytes limits the size of the decompressed request bodies,
+	// rejecting bigger ones with a 413 HTTP error. Zero disables the limit.
+	MaxDecompressedBytes int64
+	// AmbiguityHandler is called, without changing the dispatch, when more
+	// than one route matches an incoming request, receiving the information
+	// of every matched route. It helps to detect shadowed routes. The routes
+	// are only checked if it is defined.
+	AmbiguityHandler func(req *http.Request, matched []RouteInfo)
+	// RateLimitJSON makes the rate limited responses include a JSON body with
+	// the limit, the remaining requests and the reset time.
+	RateLimitJSON bool
+	// Recover enables the recovery of the panics raised by the route
+	// handlers, responding with a 500 HTTP error and sending the panic to the
+	// errors channel of the Handler.
+	Recover bool
+	// RecoverHandler replaces the default 500 HTTP error response to the
+	// recovered panics, receiving the panic value. Defining it also enables
+	// the recovery.
+	RecoverHandler func(w http.ResponseWriter, r *http.Request, rec any)
+	// PanicStackDepth limits the number of frames of the stack trace reported
+	// with the recovered panics, starting from the frame that raised the
+	// panic. Zero reports the full stack trace.
+	PanicStackDepth int
+	// LimiterStore replaces the default in-memory storage of the rate
+	// limiters. It is only used if the RateLimitConfig is defined.
+	LimiterStore LimiterStore
+	// RateLimitFailClosed makes the requests fail with a 503 HTTP error when
+	// the LimiterStore returns an error. By default, the requests are allowed
+	// (fail-open), so an unavailable store does not take the service down.
+	// The errors are sent to the errors channel in both cases.
+	RateLimitFailClosed bool
+	// MaxPathLength limits the length of the paths of the registered routes.
+	// Zero disables the limit.
+	MaxPathLength int
+	// MaxPathParams limits the number of named arguments of the paths of the
+	// registered routes. Zero disables the limit.
+	MaxPathParams int
+	// RouteMatchObserver is called with the time spent finding the route that
+	// matches each request and decoding its arguments, isolating the router
+	// overhead from the handlers execution time.
+	RouteMatchObserver func(d time.Duration)
+	// RateKeyFromContext returns the key to identify the client of a request
+	// for rate limiting from its context, for example, the user id resolved by
+	// an authentication middleware. If it returns false, the key returned by
+	// RateLimitConfig.Key or the client IP is used. The Handler applies the rate limiter before running the route
+	// handler, so the value must be stored in the context by a middleware that
+	// wraps the whole Handler.
+	RateKeyFromContext func(context.Context) (string, bool)
+	// RateLimitExempt contains the IP addresses and CIDR ranges of the
+	// clients that are never rate limited, like health checks or monitoring
+	// services. Invalid entries are ignored.
+	RateLimitExempt []string
+	// TrustedProxies contains the IP addresses and CIDR ranges of the proxies
+	// whose forwarded headers are trusted, for example, by ExternalURL.
+	// Invalid entries are ignored.
+	TrustedProxies []string
+	// ForwardedHeaders contains the headers used to resolve the client of
+	// the requests received from trusted proxies, in order. By default,
+	// 'X-Forwarded-For' and then 'X-Real-IP'.
+	ForwardedHeaders []string
+	// TrustedProxyHops defines the number of entries to skip from the right
+	// of the 'X-Forwarded-For' header to find the client, which is the
+	// number of trusted proxies in front of the Handler. If it is zero, the
+	// entries of the trusted proxies are skipped.
+	TrustedProxyHops int
+	// TrailingSlash defines how the request paths that end with a slash are
+	// matched: ignoring the slash (TrailingSlashLenient, the default), as
+	// distinct paths (TrailingSlashStrict) or redirecting them to the path
+	// without it (TrailingSlashRedirect).
+	TrailingSlash TrailingSlashMode
+	// RedirectTrailingSlash redirects the requests whose path ends with a
+	// slash to the path without it, if it is registered for the request
+	// method, to canonicalize the paths. It is equivalent to setting
+	// TrailingSlash to TrailingSlashRedirect.
+	RedirectTrailingSlash bool
+	// RedirectStatus overrides the status code of the trailing slash
+	// redirects. By default, 301 is used for GET and HEAD requests and 308
+	// for the rest, which preserves their method and body.
+	RedirectStatus int
+	// MaxBodyBytes limits the size of the request bodies, responding with
+	// the RequestEntityTooLargeHandler when it is exceeded. The routes can
+	// define their own limit using WithMaxBody. Zero disables the limit.
+	MaxBodyBytes int64
+	// RequestEntityTooLargeHandler responds to the requests whose body
+	// exceeds the limit, instead of the default 413 HTTP error.
+	RequestEntityTooLargeHandler http.HandlerFunc
+	// Encoder encodes the values returned by the ValueHandlers registered
+	// using `Handler.HandleValue`. JSONEncoder is used by default.
+	Encoder Encoder
+	// ErrorHandler responds to the errors returned by the ValueHandlers.
+	// By default, it responds with the status code of the errors that
+	// implement a 'StatusCode() int' method, or with a 500 HTTP error.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+	// NotFound handles the requests that do not match any route, as the
+	// handler assigned using `Handler.Fallback`.
+	NotFound http.HandlerFunc
+	// MaintenanceExemptPaths contains the request paths that are served as
+	// usual when the maintenance mode is enabled, like health checks.
+	MaintenanceExemptPaths []string
+	// AccessLogger writes the access logs of the Logging middleware included
+	// by DefaultMiddleware. If it is nil, the standard logger is used.
+	AccessLogger *log.Logger
 	*RateLimitConfig
 }
 
 // Handler struct cotains the list of assigned routes and also an error channel
-// to listen to raised errors using `Handler.Error(error)`.
+// to listen to raised errors using `Handler.Errors()`, where the handlers can
+// report errors using `Handler.Error(error)`.
 type Handler struct {
-	mtx         *sync.Mutex
-	routes      []*route
-	rateLimiter *rateLimiter
-	cors        bool
+	mtx           *sync.RWMutex
+	routes        []*route
+	tree          *node
+	seq           uint64
+	exact         map[string]map[string]*route
+	limiter       LimiterStore
+	rate          float64
+	burst         int
+	cors          bool
+	strictCORS    bool
+	allowOrigins  []string
+	credentials   bool
+	allowHeaders  []string
+	exposeHeaders []string
+	corsMaxAge    time.Duration
+	maxSegments   int
+	fallback      http.HandlerFunc
+	fallbacks     map[string]http.HandlerFunc
+	notAllowed    map[string]http.HandlerFunc
+	decompress    bool
+	maxInflated   int64
+	maxBody       int64
+	tooLarge      http.HandlerFunc
+	ambiguity     func(*http.Request, []RouteInfo)
+	rateLimitJSON bool
+	recover       bool
+	onPanic       func(http.ResponseWriter, *http.Request, any)
+	stackDepth    int
+	errs          chan error
+	encoder       Encoder
+	onError       func(http.ResponseWriter, *http.Request, error)
+	maxPathLen    int
+	maxPathParams int
+	matchObserver func(time.Duration)
+	rateKey       func(context.Context) (string, bool)
+	rateReqKey    func(*http.Request) (string, bool)
+	failClosed    bool
+	mws           []Middleware
+	maintenance   *maintenanceMode
+	exempt        map[string]bool
+	slashMode     TrailingSlashMode
+	redirectCode  int
+	proxies       []netip.Prefix
+	fwdHeaders    []string
+	proxyHops     int
+	rateExempt    []netip.Prefix
+	mounts        []mount
 }
 
-// NewHandler function returns a Handler initialized and read-to-use.
-func NewHandler(cfg *Config) *Handler {
-	if cfg == nil {
-		cfg = &Config{}
+// NewHandler function returns a Handler initialized and read-to-use with the
+// provided options applied in order. A *Config is also an Option, which
+// replaces the whole config, so `NewHandler(&Config{...})` and
+// `NewHandler(nil)` keep working, and it can be combined with the functional
+// options (e.g. `NewHandler(cfg, WithNotFound(h))`). Nil options are ignored.
+func NewHandler(opts ...Option) *Handler {
+	cfg := &Config{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(cfg)
+		}
 	}
 
-	var rl *rateLimiter
+	var (
+		limiter LimiterStore
+		r       float64
+		b       int
+	)
+	var key func(*http.Request) (string, bool)
 	if cfg.RateLimitConfig != nil {
-		rl = &rateLimiter{
-			r: rate.Limit(cfg.Rate),
-			b: cfg.Limit,
+		limiter, r, b, key = cfg.LimiterStore, cfg.Rate, cfg.Limit, cfg.Key
+		if limiter == nil {
+			limiter = &rateLimiter{ttl: cfg.TTL}
 		}
 	}
-	return &Handler{
-		mtx:         &sync.Mutex{},
-		routes:      []*route{},
-		rateLimiter: rl,
-		cors:        cfg.CORS,
+	exempt := map[string]bool{}
+	for _, path := range cfg.MaintenanceExemptPaths {
+		exempt[path] = true
+	}
+	handler := &Handler{
+		mtx:           &sync.RWMutex{},
+		routes:        []*route{},
+		tree:          newNode(),
+		exact:         map[string]map[string]*route{},
+		limiter:       limiter,
+		rate:          r,
+		burst:         b,
+		cors:          cfg.CORS,
+		strictCORS:    cfg.StrictPreflight,
+		allowOrigins:  cfg.AllowedOrigins,
+		credentials:   cfg.AllowCredentials && len(cfg.AllowedOrigins) > 0,
+		allowHeaders:  cfg.AllowedHeaders,
+		exposeHeaders: cfg.ExposedHeaders,
+		corsMaxAge:    cfg.CORSMaxAge,
+		maxSegments:   cfg.MaxPathSegments,
+		fallbacks:     map[string]http.HandlerFunc{},
+		notAllowed:    map[string]http.HandlerFunc{},
+		decompress:    cfg.DecompressRequests,
+		maxInflated:   cfg.MaxDecompressedBytes,
+		ambiguity:     cfg.AmbiguityHandler,
+		rateLimitJSON: cfg.RateLimitJSON,
+		recover:       cfg.Recover || cfg.RecoverHandler != nil,
+		onPanic:       cfg.RecoverHandler,
+		stackDepth:    cfg.PanicStackDepth,
+		errs:          make(chan error, errorsBufferSize),
+		maxPathLen:    cfg.MaxPathLength,
+		maxPathParams: cfg.MaxPathParams,
+		matchObserver: cfg.RouteMatchObserver,
+		rateKey:       cfg.RateKeyFromContext,
+		rateReqKey:    key,
+		failClosed:    cfg.RateLimitFailClosed,
+		exempt:        exempt,
+		slashMode:     cfg.TrailingSlash,
+		redirectCode:  cfg.RedirectStatus,
+		proxies:       parsePrefixes(cfg.TrustedProxies),
+		rateExempt:    parsePrefixes(cfg.RateLimitExempt),
+		fwdHeaders:    cfg.ForwardedHeaders,
+		proxyHops:     cfg.TrustedProxyHops,
+		fallback:      cfg.NotFound,
+		maxBody:       cfg.MaxBodyBytes,
+		tooLarge:      cfg.RequestEntityTooLargeHandler,
+		encoder:       cfg.Encoder,
+		onError:       cfg.ErrorHandler,
+	}
+	if handler.fwdHeaders == nil {
+		handler.fwdHeaders = defaultForwardedHeaders
+	}
+	if handler.encoder == nil {
+		handler.encoder = JSONEncoder{}
+	}
+	if handler.onError == nil {
+		handler.onError = handler.defaultErrorHandler
+	}
+	if cfg.RedirectTrailingSlash {
+		handler.slashMode = TrailingSlashRedirect
+	}
+	return handler
+}
+
+// NewHandlerWithCORS function is a shortcut of NewHandler that only defines
+// if CORS is enabled, using the default values for the rest of the config.
+func NewHandlerWithCORS(cors bool) *Handler {
+	return NewHandler(&Config{CORS: cors})
+}
+
+// RateLimitConfig method returns if the rate limiter of the current handler is
+// enabled and, if it is, the rate and the burst size that it is using.
+func (m *Handler) RateLimitConfig() (bool, float64, int) {
+	if m.limiter == nil {
+		return false, 0, 0
 	}
+	return true, m.rate, m.burst
 }
 
 // ServerHTTP method implements `http.Handler` interface. This funcion is
@@ -138,44 +902,298 @@ func NewHandler(cfg *Config) *Handler {
 // it is not registered yet, the function sends a response with a 405 HTTP
 // error.
 func (m *Handler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
-	// check if rate limiter is enabled and if the request is allowed
-	if m.rateLimiter != nil {
-		limiter := m.rateLimiter.Get(req.RemoteAddr)
-		if !limiter.Allow() {
+	// store the time when the request is received
+	req = req.WithContext(context.WithValue(req.Context(), startTimeKey, time.Now()))
+	// mark the requests received from trusted proxies
+	if m.isTrustedProxy(req.RemoteAddr) {
+		req = req.WithContext(withTrustedProxy(req.Context(), m.forwardedClient(req)))
+	}
+	// respond with the maintenance page if the maintenance mode is enabled
+	// and the request path is not exempted
+	if maintenance := m.maintenanceMode(); maintenance != nil && !m.exempt[req.URL.Path] {
+		maintenance.serve(res)
+		return
+	}
+	// check if rate limiter is enabled and if the request is allowed, unless
+	// the client is exempted
+	if m.limiter != nil && !containsAddr(m.rateExempt, ClientIP(req)) {
+		allowed, reset, err := m.allow(res, m.clientKey(req))
+		if !allowed && err != nil {
+			http.Error(res, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		if !allowed {
+			if m.rateLimitJSON {
+				m.writeRateLimited(res, reset)
+				return
+			}
 			http.Error(res, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
 			return
 		}
 	}
+	// check if the request path exceeds the maximum number of segments
+	if m.maxSegments > 0 {
+		path, _ := strings.CutSuffix(req.URL.Path, uriSeparator)
+		if strings.Count(path, uriSeparator) > m.maxSegments {
+			http.Error(res, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
 	// check if CORS is enabled and set headers
 	if m.cors {
-		res.Header().Set("Access-Control-Allow-Origin", "*")
-		res.Header().Set("Access-Control-Allow-Headers", "*")
-		res.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS, CONNECT, TRACE")
+		m.setCORSHeaders(res, req)
 		if req.Method == http.MethodOptions {
+			// allow the methods of the routes registered for the requested
+			// path, skipping the implicit ones
+			if methods := m.preflightMethods(matchPath(req.URL), false); len(methods) > 0 {
+				res.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			}
+			if m.corsMaxAge > 0 {
+				res.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(m.corsMaxAge.Seconds())))
+			}
+			// if strict preflight is enabled, the requested method must be
+			// registered for the requested path
+			if m.strictCORS {
+				reqMethod := req.Header.Get("Access-Control-Request-Method")
+				if !slices.Contains(m.preflightMethods(matchPath(req.URL), true), reqMethod) {
+					m.report(fmt.Errorf("%w: method '%s' not registered for '%s'", ErrPreflightRejected, reqMethod, req.URL.Path))
+					http.Error(res, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
 			res.WriteHeader(http.StatusOK)
 			return
 		}
 	}
-	// find route and execute handler
-	if route, exist := m.find(req.Method, req.URL.Path); exist {
-		if args, ok := route.decodeArgs(req.URL.Path); ok {
-			for key, val := range args {
-				req.Header.Set(key, val)
+	// delegate the request to the sub-Handler mounted at its path prefix, if
+	// any, removing the prefix from the request path
+	if mnt, path, ok := m.mountFor(req.URL.Path); ok {
+		mnt.sub.ServeHTTP(res, stripPrefix(req, mnt.prefix, path))
+		return
+	}
+	// decompress the request body if it is enabled
+	if m.decompress {
+		if err := decompressRequest(req, m.maxInflated); err != nil {
+			m.report(fmt.Errorf("error decompressing request body of '%s': %w", req.URL.Path, err))
+			if errors.Is(err, errDecompressedTooLarge) {
+				http.Error(res, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
 			}
-			route.handler(res, req)
+			http.Error(res, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
 	}
-	// if no route is found, return 405 Method Not Allowed
+	// redirect to the canonical path without the trailing slash if it is
+	// enabled and the canonical path is registered
+	if m.slashMode == TrailingSlashRedirect && m.redirectTrailingSlash(res, req) {
+		return
+	}
+	// find route and decode its arguments
+	matchStart := time.Now()
+	// the routes are matched against the decoded path keeping the escaped
+	// slashes, so they do not split the segments, and the arguments are
+	// unescaped when they are decoded
+	path := matchPath(req.URL)
+	route, args, exist := m.findAndDecode(req.Method, path)
+	if m.matchObserver != nil {
+		m.matchObserver(time.Since(matchStart))
+	}
+	// execute the route handler
+	if exist {
+		// report the matched routes if more than one route matches
+		if m.ambiguity != nil {
+			if matched := m.findAll(route.method, path); len(matched) > 1 {
+				m.ambiguity(req, matched)
+			}
+		}
+		if route.noCompression {
+			req = req.WithContext(disableCompression(req.Context()))
+		}
+		for key, val := range args {
+			req.Header.Set(key, val)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), routeKey, RouteContext{
+			Method:   route.method,
+			Path:     route.path,
+			Tags:     route.tags,
+			Params:   args,
+			LogLevel: route.logLevel,
+			Key:      route.key,
+		}))
+		route.setDeadlines(res)
+		handler := chain(route.handler, m.middlewares()...)
+		// limit the request body if the route or the handler define a limit
+		if limit := route.bodyLimit(m.maxBody); limit > 0 {
+			handler = m.limitBody(handler, limit)
+		}
+		m.serve(handler, res, req)
+		return
+	}
+	// if no route is found, use the method not allowed handler of the
+	// routes that match the request path, the fallback handler of the
+	// request method or the global one if they are registered, if not,
+	// return 405 Method Not Allowed
+	if notAllowed := m.notAllowedFor(path); notAllowed != nil {
+		notAllowed(res, req)
+		return
+	}
+	if fallback := m.fallbackFor(req.Method); fallback != nil {
+		fallback(res, req)
+		return
+	}
 	http.Error(res, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
 
+// serve method executes the provided route handler. If the recovery is
+// enabled, it recovers the handler from panics, sending the panic and its
+// stack trace (limited to the configured depth) wrapped in an error to the
+// errors channel and responding with the configured RecoverHandler or with a
+// 500 HTTP error, unless the handler already started its response. The
+// http.ErrAbortHandler panics are not recovered, so net/http can abort the
+// response as intended.
+func (m *Handler) serve(handler http.HandlerFunc, res http.ResponseWriter, req *http.Request) {
+	if !m.recover {
+		handler(res, req)
+		return
+	}
+	rw := &responseWriter{ResponseWriter: res}
+	defer func() {
+		if rec := recover(); rec != nil {
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			m.report(fmt.Errorf("%w on '%s': %v\n%s", ErrHandlerPanic, req.URL.Path, rec, panicStack(m.stackDepth)))
+			if rw.written() {
+				return
+			}
+			if m.onPanic != nil {
+				m.onPanic(rw, req, rec)
+				return
+			}
+			http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}()
+	handler(rw, req)
+}
+
+// ResetRateLimit method removes the rate limiter of the provided client key,
+// so the next request of the client is allowed immediately, for example, after
+// the user verifies the account. The key is the one used by the Handler to
+// identify the client, the result of the configured RateKeyFromContext or the
+// client address. It does nothing if the rate limit is disabled or if the
+// configured LimiterStore does not implement a 'Reset(key string)' method.
+func (m *Handler) ResetRateLimit(key string) {
+	if store, ok := m.limiter.(limiterResetter); ok {
+		store.Reset(key)
+	}
+}
+
+// RateLimitRemaining method returns the number of requests that the client of
+// the provided key can still perform immediately, for example, to build a
+// quota dashboard. Clients without requests yet have the full burst size
+// available. It returns -1 if the rate limit is disabled or if the configured
+// LimiterStore does not implement a 'Remaining(key string) int' method.
+func (m *Handler) RateLimitRemaining(key string) int {
+	store, ok := m.limiter.(limiterRemainer)
+	if !ok {
+		return -1
+	}
+	if remaining := store.Remaining(key); remaining >= 0 {
+		return remaining
+	}
+	return m.burst
+}
+
+// RateLimitClients method returns the keys of the clients tracked by the rate
+// limiter, for example, to inspect them from an administrative tool. It
+// returns nil if the rate limit is disabled or if the configured LimiterStore
+// does not implement a 'Clients() []string' method.
+func (m *Handler) RateLimitClients() []string {
+	if store, ok := m.limiter.(limiterLister); ok {
+		return store.Clients()
+	}
+	return nil
+}
+
+// ClearRateLimits method removes the rate limiters of every client, so their
+// next requests are allowed immediately, for example, after a wave of false
+// positives. It does nothing if the rate limit is disabled or if the
+// configured LimiterStore does not implement a 'Clear()' method.
+func (m *Handler) ClearRateLimits() {
+	if store, ok := m.limiter.(limiterClearer); ok {
+		store.Clear()
+	}
+}
+
+// clientKey method returns the key that identifies the client of the provided
+// request for rate limiting. It uses the key provided by the configured
+// RateKeyFromContext function if it is available, or falls back to
+// rateLimitKey with the configured RateLimitConfig.Key function.
+func (m *Handler) clientKey(req *http.Request) string {
+	if m.rateKey != nil {
+		if key, ok := m.rateKey(req.Context()); ok {
+			return key
+		}
+	}
+	return rateLimitKey(req, m.rateReqKey)
+}
+
+// rateLimitedBody struct defines the JSON body of the rate limited responses.
+type rateLimitedBody struct {
+	Error     string `json:"error"`
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	Reset     string `json:"reset"`
+}
+
+// writeRateLimited method writes a 429 HTTP error with a JSON body that
+// describes the limit, the remaining requests and the reset time provided.
+func (m *Handler) writeRateLimited(res http.ResponseWriter, reset time.Time) {
+	body, err := json.Marshal(rateLimitedBody{
+		Error:     "rate limited",
+		Limit:     m.burst,
+		Remaining: 0,
+		Reset:     reset.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		http.Error(res, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusTooManyRequests)
+	_, _ = res.Write(body)
+}
+
+// Use method appends the provided middlewares to the global chain of the
+// Handler, which wraps the handler of every matched route after the rate
+// limiting and CORS checks. The middlewares are applied in registration order,
+// so the first one is the outermost layer.
+func (m *Handler) Use(mws ...Middleware) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.mws = append(m.mws, mws...)
+}
+
+// middlewares method returns the global chain of middlewares of the Handler.
+func (m *Handler) middlewares() []Middleware {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.mws
+}
+
 // HandleFunc method assign the provided handler for requests sent to the
 // desired method and path. It checks if the method provided is already
 // supported before assign it. It also transform the provided path into a regex
-// and assign it to the created route. If already exists a route with the same
-// method and path, it will be overwritten.
-func (m *Handler) HandleFunc(method, path string, handler func(http.ResponseWriter, *http.Request)) error {
+// and assign it to the created route. The route options provided are applied
+// to the created route. If already exists a route with the same
+// method and path, it will be overwritten, but if the path is different and
+// matches the same request URIs than the path of a route already registered
+// for the method (e.g. '/users/{id}' and '/users/{name}'), an error wrapping
+// ErrRouteConflict is returned. The GET routes also register an
+// implicit HEAD route with the same handler discarding the response body,
+// unless a HEAD route is explicitly registered for the same path, which
+// always overwrites the implicit one.
+func (m *Handler) HandleFunc(method, path string, handler func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
 	for _, supported := range supportedMethods {
 		if supported == method {
 			m.mtx.Lock()
@@ -186,79 +1204,568 @@ func (m *Handler) HandleFunc(method, path string, handler func(http.ResponseWrit
 				path:    path,
 				handler: handler,
 			}
+			for _, opt := range opts {
+				opt(newRoute)
+			}
+			if err := m.validatePath(path); err != nil {
+				return fmt.Errorf("error registering route '%s': %w", path, err)
+			}
 			if err := newRoute.parse(); err != nil {
 				return fmt.Errorf("error registering route '%s': %w", path, err)
 			}
-			// try to overwrite if already exist a registered handler for it
-			for i, r := range m.routes {
-				if r.method == method && r.path == path {
-					m.routes[i] = newRoute
-					return nil
+			if r, ok := m.conflicting(newRoute); ok {
+				return fmt.Errorf("error registering route '%s': %w with '%s'", path, ErrRouteConflict, r.path)
+			}
+			if method == http.MethodHead {
+				m.replaceImplicit(newRoute)
+			}
+			m.register(newRoute)
+			// register also the HEAD route for the GET ones, unless a HEAD
+			// route was explicitly registered for the same path
+			if method == http.MethodGet {
+				if head, ok := m.registered(http.MethodHead, path); !ok || head.implicit {
+					m.register(implicitHead(newRoute))
 				}
 			}
-			// if it does not exists, create it
-			m.routes = append(m.routes, newRoute)
 			return nil
 		}
 	}
 	return fmt.Errorf("method not allowed")
 }
 
+// register method stores the provided route in the list of routes and in the
+// routes tree, and its method not allowed handler, if it is defined, for its
+// path. If already exists a route with the same method and path, it is
+// overwritten keeping its registration order. It must be called with the
+// mutex locked.
+func (m *Handler) register(newRoute *route) {
+	if newRoute.notAllowed != nil {
+		m.notAllowed[newRoute.path] = newRoute.notAllowed
+	}
+	for i, r := range m.routes {
+		if r.method == newRoute.method && r.path == newRoute.path {
+			newRoute.seq = r.seq
+			m.routes[i] = newRoute
+			m.tree.insert(newRoute)
+			return
+		}
+	}
+	m.seq++
+	newRoute.seq = m.seq
+	m.routes = append(m.routes, newRoute)
+	m.tree.insert(newRoute)
+}
+
+// conflicting method returns the route registered for the method of the
+// provided one with a different path that would match every request URI
+// matched by the new route before it, so the new one would never be used:
+// routes with the same pattern, or routes whose segments cover the segments
+// of the new one (see shadows). The implicit HEAD routes never conflict, since
+// the explicit ones replace them. It must be called with the mutex locked.
+func (m *Handler) conflicting(newRoute *route) (*route, bool) {
+	pattern := newRoute.pattern()
+	for _, r := range m.routes {
+		if r.method != newRoute.method || r.implicit || r.path == newRoute.path {
+			continue
+		}
+		if r.pattern() == pattern || (!r.prefix && !newRoute.prefix && shadows(r.path, newRoute.path)) {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// shadows function returns if every request URI matched by the provided path
+// is also matched by the provided covering path, because every segment of
+// the path is equal to the segment of the covering path at the same
+// position, or it is covered by an unconstrained named argument ('{id}') of
+// the covering path, or the last segments of the path are covered by its
+// catch-all argument ('{path...}'). The constrained and optional arguments of
+// the covering path only cover the same segments, so some overlaps, like
+// '/users/{id:[0-9]+}' and '/users/{id:[0-9]{2}}', are not detected.
+func shadows(covering, path string) bool {
+	coverSegments, segments := pathSegments(covering), pathSegments(path)
+	// a final optional argument also matches the URIs without its segment
+	if len(segments) > 0 && optionalArgToRgx.MatchString(uriSeparator+segments[len(segments)-1]) {
+		return false
+	}
+	for i, cover := range coverSegments {
+		if i == len(coverSegments)-1 && catchAllArgToRgx.MatchString(uriSeparator+cover) {
+			return len(segments) > i
+		}
+		if i >= len(segments) {
+			return false
+		}
+		segment := segments[i]
+		unconstrained := strings.HasPrefix(cover, "{") && strings.HasSuffix(cover, "}") &&
+			!strings.ContainsAny(cover, ":?") && !strings.HasSuffix(cover, "...}")
+		if segment != cover && (!unconstrained || catchAllArgToRgx.MatchString(uriSeparator+segment)) {
+			return false
+		}
+	}
+	return len(coverSegments) == len(segments)
+}
+
+// pathSegments function returns the segments of the provided route path,
+// without its leading and trailing slashes, ignoring the slashes of the
+// constraints of its named arguments. The root path has no segments.
+func pathSegments(path string) []string {
+	path = strings.Trim(path, uriSeparator)
+	segments := []string{}
+	if path == "" {
+		return segments
+	}
+	for {
+		sep := strings.Index(path, uriSeparator)
+		if start, end, ok := nextArg(path); ok && sep > start && sep < end {
+			if next := strings.Index(path[end:], uriSeparator); next >= 0 {
+				sep = end + next
+			} else {
+				sep = -1
+			}
+		}
+		if sep < 0 {
+			return append(segments, path)
+		}
+		segments, path = append(segments, path[:sep]), path[sep+1:]
+	}
+}
+
+// replaceImplicit method unregisters the implicit HEAD routes with the same
+// pattern than the provided explicit HEAD route but a different path, which
+// would be matched before it, so the explicit route replaces them as it does
+// with the implicit route of its own path. It must be called with the mutex
+// locked.
+func (m *Handler) replaceImplicit(head *route) {
+	pattern := head.pattern()
+	replaced := []*route{}
+	for _, r := range m.routes {
+		if r.method == http.MethodHead && r.implicit && r.path != head.path && r.pattern() == pattern {
+			replaced = append(replaced, r)
+		}
+	}
+	for _, r := range replaced {
+		m.unregister(r)
+	}
+}
+
+// registered method returns the route registered for the provided method and
+// path, if it exists. It must be called with the mutex locked.
+func (m *Handler) registered(method, path string) (*route, bool) {
+	for _, r := range m.routes {
+		if r.method == method && r.path == path {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// implicitHead function returns a copy of the provided GET route for the HEAD
+// method, marked as implicit, whose handler discards the body written by the
+// GET handler keeping the headers and the status code.
+func implicitHead(r *route) *route {
+	head := *r
+	head.method = http.MethodHead
+	head.implicit = true
+	head.handler = func(w http.ResponseWriter, req *http.Request) {
+		r.handler(&headResponseWriter{&responseWriter{ResponseWriter: w}}, req)
+	}
+	return &head
+}
+
+// Len method returns the number of routes registered in the Handler, including
+// the exact ones but not the implicit HEAD routes.
+func (m *Handler) Len() int {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	count := 0
+	for _, r := range m.routes {
+		if !r.implicit {
+			count++
+		}
+	}
+	for _, paths := range m.exact {
+		for _, r := range paths {
+			if !r.implicit {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Routes method returns the information of the routes registered in the
+// Handler, including the exact ones but not the implicit HEAD routes, with
+// their paths as they were registered. The routes are sorted by path and
+// method.
+func (m *Handler) Routes() []RouteInfo {
+	m.mtx.RLock()
+	routes := []RouteInfo{}
+	for _, r := range m.routes {
+		if !r.implicit {
+			routes = append(routes, RouteInfo{Method: r.method, Path: r.path})
+		}
+	}
+	for _, paths := range m.exact {
+		for _, r := range paths {
+			if !r.implicit {
+				routes = append(routes, RouteInfo{Method: r.method, Path: r.path})
+			}
+		}
+	}
+	m.mtx.RUnlock()
+	slices.SortFunc(routes, func(a, b RouteInfo) int {
+		if a.Path != b.Path {
+			return strings.Compare(a.Path, b.Path)
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return routes
+}
+
+// HandleFuncIf method wraps `Handler.HandleFunc` registering the route only if
+// the provided enabled flag is true, otherwise it does nothing and returns
+// nil. It allows to register conditional routes (for example, debug routes
+// only in non-production environments) without wrapping every call in an if.
+func (m *Handler) HandleFuncIf(enabled bool, method, path string, h http.HandlerFunc, opts ...RouteOption) error {
+	if !enabled {
+		return nil
+	}
+	return m.HandleFunc(method, path, h, opts...)
+}
+
+// Stub method registers a placeholder route for the provided method and path
+// that responds with a 501 HTTP error including the method and the path in
+// the body. It allows to declare planned routes, which are registered as any
+// other route, before implementing them.
+func (m *Handler) Stub(method, path string) error {
+	return m.HandleFunc(method, path, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, fmt.Sprintf("%s: %s %s", http.StatusText(http.StatusNotImplemented), method, path), http.StatusNotImplemented)
+	})
+}
+
+// validatePath method checks that the provided route path does not exceed the
+// maximum length and number of named arguments configured.
+func (m *Handler) validatePath(path string) error {
+	if m.maxPathLen > 0 && len(path) > m.maxPathLen {
+		return fmt.Errorf("path too long: %d characters, max %d", len(path), m.maxPathLen)
+	}
+	if m.maxPathParams > 0 {
+		if params := len(argsToRgx.FindAllString(path, -1)); params > m.maxPathParams {
+			return fmt.Errorf("too many path params: %d, max %d", params, m.maxPathParams)
+		}
+	}
+	return nil
+}
+
+// Exact method assigns the provided handler for requests sent to the desired
+// method and exact path. Unlike `Handler.HandleFunc`, the path is not parsed,
+// so it does not support named arguments and it is matched comparing it with
+// the request URI, even if it contains regex metacharacters. Exact routes are
+// checked before the rest of routes. If already exists an exact route with the
+// same method and path, it will be overwritten. As `Handler.HandleFunc` does,
+// the GET routes also register an implicit HEAD route.
+func (m *Handler) Exact(method, path string, h http.HandlerFunc) error {
+	if !isSupportedMethod(method) {
+		return fmt.Errorf("method not allowed")
+	}
+	if err := m.validatePath(path); err != nil {
+		return fmt.Errorf("error registering route '%s': %w", path, err)
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if _, ok := m.exact[method]; !ok {
+		m.exact[method] = map[string]*route{}
+	}
+	newRoute := &route{
+		method:  method,
+		path:    path,
+		handler: h,
+		exact:   true,
+	}
+	m.exact[method][path] = newRoute
+	// register also the HEAD route for the GET ones, unless a HEAD route was
+	// explicitly registered for the same path
+	if method == http.MethodGet {
+		if head, ok := m.exact[http.MethodHead][path]; !ok || head.implicit {
+			if _, ok := m.exact[http.MethodHead]; !ok {
+				m.exact[http.MethodHead] = map[string]*route{}
+			}
+			m.exact[http.MethodHead][path] = implicitHead(newRoute)
+		}
+	}
+	return nil
+}
+
 // Get method wraps `Handler.HandleFunc` for HTTP method 'GET'.
-func (m *Handler) Get(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodGet, p, h)
+func (m *Handler) Get(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodGet, p, h, opts...)
+}
+
+// GetKeyed method wraps `Handler.Get` assigning the provided key to the route,
+// which is exposed to the handler through RouteKey.
+func (m *Handler) GetKeyed(p, key string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.Get(p, h, append(opts, WithRouteKey(key))...)
 }
 
 // Head method wraps `Handler.HandleFunc` for HTTP method 'HEAD'.
-func (m *Handler) Head(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodHead, p, h)
+func (m *Handler) Head(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodHead, p, h, opts...)
 }
 
 // Post method wraps `Handler.HandleFunc` for HTTP method 'POST'.
-func (m *Handler) Post(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodPost, p, h)
+func (m *Handler) Post(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodPost, p, h, opts...)
 }
 
 // Put method wraps `Handler.HandleFunc` for HTTP method 'PUT'.
-func (m *Handler) Put(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodPut, p, h)
+func (m *Handler) Put(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodPut, p, h, opts...)
 }
 
 // Patch method wraps `Handler.HandleFunc` for HTTP method 'PATCH'.
-func (m *Handler) Patch(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodPatch, p, h)
+func (m *Handler) Patch(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodPatch, p, h, opts...)
 }
 
 // Delete method wraps `Handler.HandleFunc` for HTTP method 'DELETE'.
-func (m *Handler) Delete(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodDelete, p, h)
+func (m *Handler) Delete(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodDelete, p, h, opts...)
 }
 
 // Connect method wraps `Handler.HandleFunc` for HTTP method 'CONNECT'.
-func (m *Handler) Connect(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodConnect, p, h)
+func (m *Handler) Connect(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodConnect, p, h, opts...)
 }
 
 // Options method wraps `Handler.HandleFunc` for HTTP method 'OPTIONS'.
-func (m *Handler) Options(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodOptions, p, h)
+func (m *Handler) Options(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodOptions, p, h, opts...)
 }
 
 // Trace method wraps `Handler.HandleFunc` for HTTP method 'TRACE'.
-func (m *Handler) Trace(p string, h func(http.ResponseWriter, *http.Request)) error {
-	return m.HandleFunc(http.MethodTrace, p, h)
+func (m *Handler) Trace(p string, h func(http.ResponseWriter, *http.Request), opts ...RouteOption) error {
+	return m.HandleFunc(http.MethodTrace, p, h, opts...)
 }
 
-// find method search for a registered handler for the method and request URI
-// provided, matching the routes regex with the URI provided. If the route is
-// not registered, it returns also false.
-func (m *Handler) find(method, requestURI string) (*route, bool) {
+// ArgsOffsets method returns the byte offsets of the named arguments captured
+// from the request URI provided by the route registered for the method
+// provided. Every argument name is mapped to its start and end offsets in the
+// request URI, which is useful to highlight the parts of the URI that matched.
+// It returns false if no route matches.
+func (m *Handler) ArgsOffsets(method, requestURI string) (map[string][2]int, bool) {
+	route, exist := m.find(method, requestURI)
+	if !exist {
+		return nil, false
+	}
+	return route.argsIndex(requestURI)
+}
+
+// Fallback method assigns the provided handler to the requests that do not
+// match any registered route. Method specific fallbacks registered using
+// `Handler.FallbackFor` take precedence over this one.
+func (m *Handler) Fallback(h http.HandlerFunc) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
+	m.fallback = h
+}
+
+// FallbackFor method assigns the provided handler to the requests with the
+// provided method that do not match any registered route. It takes precedence
+// over the global fallback registered using `Handler.Fallback`.
+func (m *Handler) FallbackFor(method string, h http.HandlerFunc) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.fallbacks[method] = h
+}
+
+// notAllowedFor method returns the method not allowed handler assigned to the
+// path of the enabled routes that match the provided request URI, or nil if
+// none of them has one.
+func (m *Handler) notAllowedFor(requestURI string) http.HandlerFunc {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if len(m.notAllowed) == 0 {
+		return nil
+	}
 	for _, r := range m.routes {
-		if r.method == method && r.match(requestURI) {
-			return r, true
+		if h, ok := m.notAllowed[r.path]; ok && !r.disabled && r.match(requestURI) {
+			return h
+		}
+	}
+	return nil
+}
+
+// fallbackFor method returns the fallback handler for the provided method,
+// which is the method specific one if it is registered, or the global one if
+// not. It returns nil if none of them is registered.
+func (m *Handler) fallbackFor(method string) http.HandlerFunc {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if fallback, ok := m.fallbacks[method]; ok {
+		return fallback
+	}
+	return m.fallback
+}
+
+// DisableRoute method disables the registered route with the method and path
+// provided without removing it, so requests that match it are handled as if
+// it does not exist. It returns false if the route is not registered.
+func (m *Handler) DisableRoute(method, path string) bool {
+	return m.setDisabled(method, path, true)
+}
+
+// EnableRoute method enables a route previously disabled using
+// `Handler.DisableRoute`. It returns false if the route is not registered.
+func (m *Handler) EnableRoute(method, path string) bool {
+	return m.setDisabled(method, path, false)
+}
+
+// setDisabled method sets the disabled flag of the registered route with the
+// method and path provided, including the exact routes. It returns false if
+// the route is not registered.
+func (m *Handler) setDisabled(method, path string, disabled bool) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	found := false
+	if r, ok := m.exact[method][path]; ok {
+		r.disabled = disabled
+		found = true
+	}
+	for _, r := range m.routes {
+		if r.method == method && r.path == path {
+			r.disabled = disabled
+			found = true
+		}
+	}
+	// the implicit HEAD routes follow the state of their GET routes
+	if found && method == http.MethodGet {
+		if r, ok := m.exact[http.MethodHead][path]; ok && r.implicit {
+			r.disabled = disabled
+		}
+		if r, ok := m.registered(http.MethodHead, path); ok && r.implicit {
+			r.disabled = disabled
+		}
+	}
+	return found
+}
+
+// Remove method unregisters the route with the method and path provided,
+// including the exact routes, so the requests that matched it are handled as
+// if it was never registered. Removing a GET route also removes its implicit
+// HEAD route, and removing an explicit HEAD route restores the implicit one of
+// the GET route of the same path, if it exists. It returns false if the route
+// is not registered.
+func (m *Handler) Remove(method, path string) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	removed := []*route{}
+	if r, ok := m.exact[method][path]; ok {
+		delete(m.exact[method], path)
+		removed = append(removed, r)
+	}
+	if r, ok := m.registered(method, path); ok {
+		m.unregister(r)
+		removed = append(removed, r)
+	}
+	for _, r := range removed {
+		switch {
+		case method == http.MethodGet:
+			// the implicit HEAD routes follow their GET routes
+			if r.exact {
+				if head, ok := m.exact[http.MethodHead][path]; ok && head.implicit {
+					delete(m.exact[http.MethodHead], path)
+				}
+			} else if head, ok := m.registered(http.MethodHead, path); ok && head.implicit {
+				m.unregister(head)
+			}
+		case method == http.MethodHead && !r.implicit:
+			if r.exact {
+				if get, ok := m.exact[http.MethodGet][path]; ok {
+					m.exact[http.MethodHead][path] = implicitHead(get)
+				}
+			} else if get, ok := m.registered(http.MethodGet, path); ok {
+				m.register(implicitHead(get))
+			}
 		}
 	}
+	return len(removed) > 0
+}
+
+// unregister method deletes the provided route from the list of routes and
+// from the routes tree, and the method not allowed handler of its path if no
+// other route has the same path. It must be called with the mutex locked.
+func (m *Handler) unregister(r *route) {
+	m.routes = slices.DeleteFunc(m.routes, func(existing *route) bool {
+		return existing == r
+	})
+	m.tree.remove(r)
+	if !slices.ContainsFunc(m.routes, func(existing *route) bool {
+		return existing.path == r.path
+	}) {
+		delete(m.notAllowed, r.path)
+	}
+}
+
+// find method search for a registered handler for the method and request URI
+// provided. It checks the exact routes first and then traverses the routes
+// tree by the URI segments, matching the candidates with the URI provided. If
+// the route is not registered, it returns also false.
+func (m *Handler) find(method, requestURI string) (*route, bool) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if r, ok := m.exact[method][requestURI]; ok && !r.disabled {
+		return r, true
+	}
+	match := func(r *route) bool {
+		return m.matchSlash(r, requestURI) && r.match(requestURI)
+	}
+	if r := m.tree.lookup(uriSegments(requestURI), method, match, nil); r != nil {
+		return r, true
+	}
 	return nil, false
 }
+
+// findAndDecode method returns the route for the method provided that matches
+// with the request URI provided, as `Handler.find` does, and its decoded
+// arguments, running the regex of every candidate route once to match and
+// decode them in a single pass.
+func (m *Handler) findAndDecode(method, requestURI string) (*route, map[string]string, bool) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	if r, ok := m.exact[method][requestURI]; ok && !r.disabled {
+		return r, map[string]string{}, true
+	}
+	var args map[string]string
+	decode := func(r *route) bool {
+		if !m.matchSlash(r, requestURI) {
+			return false
+		}
+		decoded, ok := r.decodeArgs(requestURI)
+		if ok {
+			args = decoded
+		}
+		return ok
+	}
+	if r := m.tree.lookup(uriSegments(requestURI), method, decode, nil); r != nil {
+		return r, args, true
+	}
+	return nil, nil, false
+}
+
+// findAll method returns the information of every registered route for the
+// method provided that matches with the request URI provided.
+func (m *Handler) findAll(method, requestURI string) []RouteInfo {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	matched := []RouteInfo{}
+	if r, ok := m.exact[method][requestURI]; ok && !r.disabled {
+		matched = append(matched, RouteInfo{Method: r.method, Path: r.path})
+	}
+	for _, r := range m.routes {
+		if r.method == method && !r.disabled && m.matchSlash(r, requestURI) && r.match(requestURI) {
+			matched = append(matched, RouteInfo{Method: r.method, Path: r.path})
+		}
+	}
+	return matched
+}
@@ -1,11 +1,16 @@
 package apihandler
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 const testMethod = http.MethodGet
@@ -43,13 +48,10 @@ func TestHandleFunc(t *testing.T) {
 func TestServerHTTP(t *testing.T) {
 	handler := NewHandler(&Config{CORS: false})
 	_ = handler.HandleFunc(http.MethodGet, testPath, testHandler)
-	go func() {
-		if err := http.ListenAndServe(":8080", handler); err != nil {
-			t.Log(err)
-		}
-	}()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
 
-	resp, err := http.Get("http://localhost:8080")
+	resp, err := http.Get(srv.URL)
 	if err != nil {
 		t.Fatalf("expected nil, got error: %s", err)
 	}
@@ -61,7 +63,7 @@ func TestServerHTTP(t *testing.T) {
 		t.Fatalf("expected 405 error, got %s", err)
 	}
 
-	resp, err = http.Get("http://localhost:8080" + testURI)
+	resp, err = http.Get(srv.URL + testURI)
 	if err != nil {
 		t.Fatalf("expected nil, got error: %s", err)
 	}
@@ -194,3 +196,1151 @@ func Test_parseAndDecodeArgs(t *testing.T) {
 		t.Fatalf("expected '0xffffff', got '%s'", value)
 	}
 }
+
+func TestStrictPreflight(t *testing.T) {
+	handler := NewHandler(&Config{CORS: true, StrictPreflight: true})
+	_ = handler.Get(testPath, testHandler)
+
+	req := httptest.NewRequest(http.MethodOptions, testURI, nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, testURI, nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodOptions, "/unknown", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", res.Code)
+	}
+}
+
+func TestPreflightAllowMethods(t *testing.T) {
+	handler := NewHandler(&Config{CORS: true})
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Post(testPath, testHandler)
+
+	req := httptest.NewRequest(http.MethodOptions, testURI, nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if value := res.Header().Get("Access-Control-Allow-Methods"); value != "GET, POST" {
+		t.Fatalf("expected 'GET, POST', got '%s'", value)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodOptions, "/unknown", nil))
+	if value := res.Header().Get("Access-Control-Allow-Methods"); value != "" {
+		t.Fatalf("expected no methods, got '%s'", value)
+	}
+}
+
+func TestArgsOffsets(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/api/{version}/user/{id}", testHandler)
+
+	if _, ok := handler.ArgsOffsets(http.MethodGet, "/api/v2"); ok {
+		t.Fatal("expected false, got true")
+	}
+	requestURI := "/api/v2/user/0xffffff"
+	offsets, ok := handler.ArgsOffsets(http.MethodGet, requestURI)
+	if !ok {
+		t.Fatal("expected true, got false")
+	}
+	if len(offsets) != 2 {
+		t.Fatalf("expected 2 offsets, got %d", len(offsets))
+	}
+	if value := offsets["version"]; value != [2]int{5, 7} {
+		t.Fatalf("expected [5 7], got %v", value)
+	} else if arg := requestURI[value[0]:value[1]]; arg != "v2" {
+		t.Fatalf("expected 'v2', got '%s'", arg)
+	}
+	if value := offsets["id"]; value != [2]int{13, 21} {
+		t.Fatalf("expected [13 21], got %v", value)
+	} else if arg := requestURI[value[0]:value[1]]; arg != "0xffffff" {
+		t.Fatalf("expected '0xffffff', got '%s'", arg)
+	}
+}
+
+func TestMaxPathSegments(t *testing.T) {
+	handler := NewHandler(&Config{MaxPathSegments: 2})
+	_ = handler.Get(testPath, testHandler)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/test/args/a/b/c", nil))
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI+"/", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "test_args" {
+		t.Fatalf("expected 'test_args', got '%s'", body)
+	}
+}
+
+func TestHeadPrecedence(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "get")
+		_, _ = w.Write([]byte("body"))
+	})
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodHead, testURI, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if value := res.Header().Get("X-Handler"); value != "get" {
+		t.Fatalf("expected 'get', got '%s'", value)
+	}
+	if res.Body.Len() != 0 {
+		t.Fatalf("expected empty body, got '%s'", res.Body.String())
+	}
+
+	_ = handler.Head(testPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "head")
+		w.Header().Set("Content-Length", "4")
+	})
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodHead, testURI, nil))
+	if value := res.Header().Get("X-Handler"); value != "head" {
+		t.Fatalf("expected 'head', got '%s'", value)
+	}
+	if value := res.Header().Get("Content-Length"); value != "4" {
+		t.Fatalf("expected '4', got '%s'", value)
+	}
+}
+
+func TestImplicitHead(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, testHandler)
+	r, exist := handler.find(http.MethodHead, testURI)
+	if !exist || !r.implicit {
+		t.Fatalf("expected implicit HEAD route")
+	}
+
+	_ = handler.Head(testPath, testHandler)
+	if r, _ = handler.find(http.MethodHead, testURI); r.implicit {
+		t.Fatalf("expected explicit HEAD route")
+	}
+	// registering the GET route again does not overwrite the explicit one
+	_ = handler.Get(testPath, testHandler)
+	if r, _ = handler.find(http.MethodHead, testURI); r.implicit {
+		t.Fatalf("expected explicit HEAD route")
+	}
+
+	// the implicit HEAD routes follow the state of their GET routes
+	_ = handler.Get("/other", testHandler)
+	handler.DisableRoute(http.MethodGet, "/other")
+	if _, exist := handler.find(http.MethodHead, "/other"); exist {
+		t.Fatalf("expected disabled implicit HEAD route")
+	}
+
+	_ = handler.Exact(http.MethodGet, "/exact", testHandler)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodHead, "/exact", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if res.Body.Len() != 0 {
+		t.Fatalf("expected empty body, got '%s'", res.Body.String())
+	}
+}
+
+func TestNewHandlerWithCORS(t *testing.T) {
+	handler := NewHandlerWithCORS(true)
+	_ = handler.Get(testPath, testHandler)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if value := res.Header().Get("Access-Control-Allow-Origin"); value != "*" {
+		t.Fatalf("expected '*', got '%s'", value)
+	}
+
+	handler = NewHandlerWithCORS(false)
+	_ = handler.Get(testPath, testHandler)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if value := res.Header().Get("Access-Control-Allow-Origin"); value != "" {
+		t.Fatalf("expected no CORS headers, got '%s'", value)
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	handler := NewHandler(nil)
+	if enabled, rate, burst := handler.RateLimitConfig(); enabled || rate != 0 || burst != 0 {
+		t.Fatalf("expected disabled rate limit, got %t %f %d", enabled, rate, burst)
+	}
+
+	handler = NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 2.5, Limit: 10}})
+	enabled, rate, burst := handler.RateLimitConfig()
+	if !enabled {
+		t.Fatal("expected true, got false")
+	}
+	if rate != 2.5 {
+		t.Fatalf("expected 2.5, got %f", rate)
+	}
+	if burst != 10 {
+		t.Fatalf("expected 10, got %d", burst)
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, testHandler)
+	handler.FallbackFor(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("spa"))
+	})
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/unknown/path", nil))
+	if body := res.Body.String(); body != "spa" {
+		t.Fatalf("expected 'spa', got '%s'", body)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if body := res.Body.String(); body != "test_args" {
+		t.Fatalf("expected 'test_args', got '%s'", body)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodDelete, "/unknown/path", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+
+	handler.Fallback(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "global", http.StatusNotFound)
+	})
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodDelete, "/unknown/path", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/unknown/path", nil))
+	if body := res.Body.String(); body != "spa" {
+		t.Fatalf("expected 'spa', got '%s'", body)
+	}
+}
+
+func TestWithSuffixes(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/pages/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s", r.Header.Get("slug"), r.Header.Get("lang"), r.Header.Get("format"))
+	}, WithSuffixes("slug", "lang", "format"))
+
+	tests := map[string]string{
+		"/pages/page.en.json":    "page|en|json",
+		"/pages/page.json":       "page||json",
+		"/pages/page":            "page||",
+		"/pages/my.page.en.json": "my.page|en|json",
+	}
+	for uri, expected := range tests {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+		if body := res.Body.String(); body != expected {
+			t.Fatalf("expected '%s' for '%s', got '%s'", expected, uri, body)
+		}
+	}
+}
+
+func TestExact(t *testing.T) {
+	handler := NewHandler(nil)
+	if err := handler.Exact("wrongmethod", "/status.json", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err := handler.Exact(http.MethodGet, "/status.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("exact"))
+	}); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/status.json", nil))
+	if body := res.Body.String(); body != "exact" {
+		t.Fatalf("expected 'exact', got '%s'", body)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/statusXjson", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+	if _, exist := handler.find(http.MethodPost, "/status.json"); exist {
+		t.Fatal("expected no handler for POST /status.json")
+	}
+}
+
+func BenchmarkExact(b *testing.B) {
+	handler := NewHandler(nil)
+	_ = handler.Exact(http.MethodGet, "/api/v1/status", testHandler)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.find(http.MethodGet, "/api/v1/status")
+	}
+}
+
+func BenchmarkRegex(b *testing.B) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/api/v1/status", testHandler)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.find(http.MethodGet, "/api/v1/status")
+	}
+}
+
+func TestAmbiguityHandler(t *testing.T) {
+	var matched []RouteInfo
+	handler := NewHandler(&Config{
+		AmbiguityHandler: func(req *http.Request, routes []RouteInfo) {
+			matched = routes
+		},
+	})
+	_ = handler.Get("/users/{id:[0-9]+}", testHandler)
+	_ = handler.Get("/users/{name}", testHandler)
+	_ = handler.Get("/groups/{id}", testHandler)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/groups/1", nil))
+	if matched != nil {
+		t.Fatalf("expected no ambiguity, got %v", matched)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if len(matched) != 2 {
+		t.Fatalf("expected 2 matched routes, got %v", matched)
+	}
+	if matched[0].Path != "/users/{id:[0-9]+}" || matched[1].Path != "/users/{name}" {
+		t.Fatalf("expected both users routes, got %v", matched)
+	}
+}
+
+func TestDisableRoute(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, testHandler)
+
+	if handler.DisableRoute(http.MethodPost, testPath) {
+		t.Fatal("expected false, got true")
+	}
+	if !handler.DisableRoute(http.MethodGet, testPath) {
+		t.Fatal("expected true, got false")
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+
+	if !handler.EnableRoute(http.MethodGet, testPath) {
+		t.Fatal("expected true, got false")
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if body := res.Body.String(); body != "test_args" {
+		t.Fatalf("expected 'test_args', got '%s'", body)
+	}
+}
+
+func TestRouteConflict(t *testing.T) {
+	handler := NewHandler(nil)
+	if err := handler.Get("/users/{id}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	// distinct routes and patterns, static routes registered before the
+	// dynamic ones, and the same path with other methods
+	_ = handler.Get("/accounts/me", testHandler)
+	for _, path := range []string{"/users/{id}/posts", "/groups/{id}", "/accounts/{id}", "/users"} {
+		if err := handler.Get(path, testHandler); err != nil {
+			t.Fatalf("expected nil for '%s', got %s", path, err)
+		}
+	}
+	if err := handler.Post("/users/{name}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	// overwriting the same path is allowed
+	if err := handler.Get("/users/{id}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	// same patterns, and routes fully shadowed by the unconstrained and
+	// catch-all arguments of the previous routes
+	_ = handler.Get("/files/{path...}", testHandler)
+	for _, path := range []string{
+		"/users/{name}", "/users/{id:[0-9]+}", "/users/me", "/users/{name}/posts",
+		"/groups/admins", "/files/{name}", "/files/a/{path...}",
+	} {
+		err := handler.Get(path, testHandler)
+		if !errors.Is(err, ErrRouteConflict) {
+			t.Fatalf("expected ErrRouteConflict for '%s', got %v", path, err)
+		}
+	}
+	// the overlaps of the constrained arguments are not detected
+	_ = handler.Get("/items/{id:[0-9]+}", testHandler)
+	if err := handler.Get("/items/{id:[0-9]{2}}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if r, ok := handler.find(http.MethodGet, "/users/john"); !ok || r.path != "/users/{id}" {
+		t.Fatalf("expected '/users/{id}', got %v", r)
+	}
+
+	// an explicit HEAD route replaces the implicit one with the same pattern
+	if err := handler.Head("/users/{name}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if r, ok := handler.find(http.MethodHead, "/users/john"); !ok || r.implicit || r.path != "/users/{name}" {
+		t.Fatalf("expected explicit '/users/{name}' HEAD route, got %v", r)
+	}
+	if err := handler.Head("/users/{user}", testHandler); !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected ErrRouteConflict, got %v", err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Post(testPath, testHandler)
+	_ = handler.Exact(http.MethodGet, "/exact", testHandler)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if body := res.Body.String(); body != "test_args" {
+		t.Fatalf("expected 'test_args', got '%s'", body)
+	}
+
+	if handler.Remove(http.MethodPut, testPath) {
+		t.Fatal("expected false, got true")
+	}
+	if !handler.Remove(http.MethodGet, testPath) {
+		t.Fatal("expected true, got false")
+	}
+	if handler.Remove(http.MethodGet, testPath) {
+		t.Fatal("expected false, got true")
+	}
+	if count := handler.Len(); count != 2 {
+		t.Fatalf("expected 2, got %d", count)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(method, testURI, nil))
+		if res.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("expected 405 for %s, got %d", method, res.Code)
+		}
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, testURI, nil))
+	if body := res.Body.String(); body != "test_args" {
+		t.Fatalf("expected 'test_args', got '%s'", body)
+	}
+
+	if !handler.Remove(http.MethodGet, "/exact") {
+		t.Fatal("expected true, got false")
+	}
+	if count := handler.Len(); count != 1 {
+		t.Fatalf("expected 1, got %d", count)
+	}
+	expected := []RouteInfo{{Method: http.MethodPost, Path: testPath}}
+	if routes := handler.Routes(); !slices.Equal(routes, expected) {
+		t.Fatalf("expected %v, got %v", expected, routes)
+	}
+	if _, ok := handler.find(http.MethodHead, "/exact"); ok {
+		t.Fatal("expected false, got true")
+	}
+
+	// removing an explicit HEAD route restores the implicit one
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.HandleFunc(http.MethodHead, testPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	if !handler.Remove(http.MethodHead, testPath) {
+		t.Fatal("expected true, got false")
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodHead, testURI, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestRateLimitJSON(t *testing.T) {
+	handler := NewHandler(&Config{
+		RateLimitJSON:   true,
+		RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1},
+	})
+	_ = handler.Get(testPath, testHandler)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", res.Code)
+	}
+	if value := res.Header().Get("Content-Type"); value != "application/json" {
+		t.Fatalf("expected 'application/json', got '%s'", value)
+	}
+	body := struct {
+		Error     string `json:"error"`
+		Limit     int    `json:"limit"`
+		Remaining int    `json:"remaining"`
+		Reset     string `json:"reset"`
+	}{}
+	if err := json.Unmarshal(res.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if body.Error != "rate limited" || body.Limit != 1 || body.Remaining != 0 {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+	reset, err := time.Parse(time.RFC3339, body.Reset)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if reset.Before(time.Now().Add(-time.Second)) || reset.After(time.Now().Add(2*time.Second)) {
+		t.Fatalf("expected reset in about one second, got %s", reset)
+	}
+}
+
+func TestWithParamNormalizer(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{email}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "email")))
+	}, WithParamNormalizer("email", strings.ToLower))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/John@Example.COM", nil))
+	if body := res.Body.String(); body != "john@example.com" {
+		t.Fatalf("expected 'john@example.com', got '%s'", body)
+	}
+}
+
+func TestOptionalArg(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/articles/{id}/{section?}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "id") + "|" + URIParam(r.Context(), "section")))
+	})
+	_ = handler.Get("/files/{bucket}/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "bucket") + "|" + URIParam(r.Context(), "path")))
+	})
+
+	tests := []struct {
+		uri, expected string
+		code          int
+	}{
+		{"/articles/5", "5|", http.StatusOK},
+		{"/articles/5/", "5|", http.StatusOK},
+		{"/articles/5/meta", "5|meta", http.StatusOK},
+		{"/articles/5/meta/other", "", http.StatusMethodNotAllowed},
+		{"/articles", "", http.StatusMethodNotAllowed},
+		{"/files/docs/a/b.txt", "docs|a/b.txt", http.StatusOK},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, test.uri, nil))
+		if res.Code != test.code {
+			t.Fatalf("expected %d for '%s', got %d", test.code, test.uri, res.Code)
+		}
+		if body := res.Body.String(); test.code == http.StatusOK && body != test.expected {
+			t.Fatalf("expected '%s' for '%s', got '%s'", test.expected, test.uri, body)
+		}
+	}
+}
+
+func TestWithParamDefault(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/list/{page?}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "page")))
+	}, WithParamDefault("page", "1"))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/list", nil))
+	if body := res.Body.String(); body != "1" {
+		t.Fatalf("expected '1', got '%s'", body)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/list/3", nil))
+	if body := res.Body.String(); body != "3" {
+		t.Fatalf("expected '3', got '%s'", body)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/list/3/other", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+}
+
+func TestArgConstraints(t *testing.T) {
+	handler := NewHandler(nil)
+	if err := handler.Get("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "id")))
+	}); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if err := handler.Get("/codes/{code:[a-z]{3}}/{name}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/123", nil))
+	if body := res.Body.String(); body != "123" {
+		t.Fatalf("expected '123', got '%s'", body)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+
+	if _, exist := handler.find(http.MethodGet, "/codes/abc/args"); !exist {
+		t.Fatal("expected route for /codes/abc/args")
+	}
+	if _, exist := handler.find(http.MethodGet, "/codes/abcd/args"); exist {
+		t.Fatal("expected no route for /codes/abcd/args")
+	}
+
+	if err := handler.Get("/users/{id:[0-9}", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	} else if !strings.Contains(err.Error(), "error registering route") {
+		t.Fatalf("expected 'error registering route' error got %s", err)
+	}
+}
+
+func TestCatchAllArg(t *testing.T) {
+	handler := NewHandler(nil)
+	if err := handler.Get("/files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "path")))
+	}); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	for _, path := range []string{"report.pdf", "docs/2024/report.pdf"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/files/"+path, nil))
+		if body := res.Body.String(); body != path {
+			t.Fatalf("expected '%s', got '%s'", path, body)
+		}
+	}
+	for _, uri := range []string{"/files", "/files/", "/other/files/report.pdf"} {
+		if _, exist := handler.find(http.MethodGet, uri); exist {
+			t.Fatalf("expected no route for %s", uri)
+		}
+	}
+
+	// the catch-all argument must be the last segment
+	if err := handler.Get("/files/{path...}/raw", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestWithPrefixMatch(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/api/{version}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "version")))
+	}, WithPrefixMatch())
+	_ = handler.Post("/", testHandler, WithPrefixMatch())
+
+	for _, uri := range []string{"/api/v1", "/api/v1/", "/api/v1/users/123"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+		if body := res.Body.String(); body != "v1" {
+			t.Fatalf("expected 'v1' for %s, got '%s'", uri, body)
+		}
+	}
+	for _, uri := range []string{"/api", "/apiv1/users", "/other/api/v1"} {
+		if _, exist := handler.find(http.MethodGet, uri); exist {
+			t.Fatalf("expected no route for %s", uri)
+		}
+	}
+	for _, uri := range []string{"/", "/users", "/users/123"} {
+		if _, exist := handler.find(http.MethodPost, uri); !exist {
+			t.Fatalf("expected route for %s", uri)
+		}
+	}
+}
+
+func TestWithMethodNotAllowed(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{id}", testHandler, WithMethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "use GET to read users", http.StatusMethodNotAllowed)
+	}))
+	_ = handler.Post("/orders", testHandler, WithMethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "use POST to create orders", http.StatusMethodNotAllowed)
+	}))
+	_ = handler.Get("/plain", testHandler)
+
+	tests := []struct {
+		method, uri, body string
+	}{
+		{http.MethodDelete, "/users/1", "use GET to read users\n"},
+		{http.MethodGet, "/orders", "use POST to create orders\n"},
+		{http.MethodPost, "/plain", http.StatusText(http.StatusMethodNotAllowed) + "\n"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(test.method, test.uri, nil))
+		if res.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("expected 405, got %d", res.Code)
+		}
+		if body := res.Body.String(); body != test.body {
+			t.Fatalf("expected '%s', got '%s'", test.body, body)
+		}
+	}
+}
+
+func TestLen(t *testing.T) {
+	handler := NewHandler(nil)
+	if count := handler.Len(); count != 0 {
+		t.Fatalf("expected 0, got %d", count)
+	}
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Post(testPath, testHandler)
+	_ = handler.Exact(http.MethodGet, "/exact", testHandler)
+	if count := handler.Len(); count != 3 {
+		t.Fatalf("expected 3, got %d", count)
+	}
+	// overwritten and disabled routes are still registered once
+	_ = handler.Get(testPath, testHandler)
+	handler.DisableRoute(http.MethodPost, testPath)
+	if count := handler.Len(); count != 3 {
+		t.Fatalf("expected 3, got %d", count)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	handler := NewHandler(nil)
+	if routes := handler.Routes(); len(routes) != 0 {
+		t.Fatalf("expected no routes, got %v", routes)
+	}
+	_ = handler.Post(testPath, testHandler)
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Delete("/resource/{id:[0-9]+}", testHandler)
+	_ = handler.Exact(http.MethodGet, "/exact", testHandler)
+	_ = handler.Get("/", testHandler)
+
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Path: "/"},
+		{Method: http.MethodGet, Path: "/exact"},
+		{Method: http.MethodDelete, Path: "/resource/{id:[0-9]+}"},
+		{Method: http.MethodGet, Path: testPath},
+		{Method: http.MethodPost, Path: testPath},
+	}
+	if routes := handler.Routes(); !slices.Equal(routes, expected) {
+		t.Fatalf("expected %v, got %v", expected, routes)
+	}
+}
+
+func TestWithDeadlines(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/fast", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fast"))
+	}, WithDeadlines(time.Second, time.Second))
+	_ = handler.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("slow"))
+	}, WithDeadlines(0, 20*time.Millisecond))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/fast")
+	if err != nil {
+		t.Fatalf("expected nil, got error: %s", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "fast" {
+		t.Fatalf("expected 'fast', got '%s' (%v)", string(body), err)
+	}
+
+	if resp, err := http.Get(srv.URL + "/slow"); err == nil {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil && string(body) == "slow" {
+			t.Fatal("expected write deadline error, got full response")
+		}
+	}
+
+	// unsupported response writers ignore the deadlines
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if body := res.Body.String(); body != "fast" {
+		t.Fatalf("expected 'fast', got '%s'", body)
+	}
+}
+
+func TestPathLimits(t *testing.T) {
+	handler := NewHandler(&Config{MaxPathLength: 20, MaxPathParams: 2})
+	if err := handler.Get("/api/{version}/{id}", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if err := handler.Get("/api/v1/very/long/path/to/resource", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	} else if !strings.Contains(err.Error(), "path too long") {
+		t.Fatalf("expected 'path too long' error, got %s", err)
+	}
+	if err := handler.Exact(http.MethodGet, "/api/v1/very/long/path/to/resource", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err := handler.Get("/{a}/{b}/{c}", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	} else if !strings.Contains(err.Error(), "too many path params") {
+		t.Fatalf("expected 'too many path params' error, got %s", err)
+	}
+	if _, exist := handler.find(http.MethodGet, "/a/b/c"); exist {
+		t.Fatal("expected no handler for /a/b/c")
+	}
+}
+
+func TestRouteMatchObserver(t *testing.T) {
+	durations := []time.Duration{}
+	handler := NewHandler(&Config{
+		RouteMatchObserver: func(d time.Duration) {
+			durations = append(durations, d)
+		},
+	})
+	_ = handler.Get(testPath, testHandler)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if len(durations) != 2 {
+		t.Fatalf("expected 2 observations, got %d", len(durations))
+	}
+	for _, d := range durations {
+		if d < 0 {
+			t.Fatalf("expected non-negative duration, got %s", d)
+		}
+	}
+}
+
+func TestStub(t *testing.T) {
+	handler := NewHandler(&Config{CORS: true, StrictPreflight: true})
+	if err := handler.Stub("wrongmethod", testPath); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if err := handler.Stub(http.MethodPost, testPath); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if _, exist := handler.find(http.MethodPost, testURI); !exist {
+		t.Fatalf("expected handler for [%s] %s", http.MethodPost, testPath)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, testURI, nil))
+	if res.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", res.Code)
+	}
+	if body := res.Body.String(); !strings.Contains(body, "POST "+testPath) {
+		t.Fatalf("expected method and path in body, got '%s'", body)
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, testURI, nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestHandleFuncIf(t *testing.T) {
+	handler := NewHandler(nil)
+	if err := handler.HandleFuncIf(false, http.MethodGet, "/debug", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if _, exist := handler.find(http.MethodGet, "/debug"); exist {
+		t.Fatal("expected no handler for /debug")
+	}
+	if err := handler.HandleFuncIf(true, http.MethodGet, "/debug", testHandler); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if _, exist := handler.find(http.MethodGet, "/debug"); !exist {
+		t.Fatal("expected handler for /debug")
+	}
+	if err := handler.HandleFuncIf(true, "wrongmethod", "/debug", testHandler); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestDecodeArgsUnescape(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{name}", testHandler)
+	r := handler.routes[0]
+
+	tests := []struct {
+		uri, expected string
+		match         bool
+	}{
+		{"/users/john", "john", true},
+		{"/users/john%20doe", "john doe", true},
+		{"/users/john%2Fdoe", "john/doe", true},
+		{"/users/john%ZZ", "", false},
+	}
+	for _, test := range tests {
+		args, ok := r.decodeArgs(test.uri)
+		if ok != test.match {
+			t.Fatalf("expected match %t for %s, got %t", test.match, test.uri, ok)
+		}
+		if ok && args["name"] != test.expected {
+			t.Fatalf("expected '%s' for %s, got '%s'", test.expected, test.uri, args["name"])
+		}
+	}
+
+	// escaped slashes do not split the request path segments
+	var name string
+	_ = handler.Get("/files/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name = r.Header.Get("name")
+	})
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/files/docs%2Freport%20final.pdf", nil))
+	if res.Code != http.StatusOK || name != "docs/report final.pdf" {
+		t.Fatalf("expected 200 with 'docs/report final.pdf', got %d with '%s'", res.Code, name)
+	}
+}
+
+func TestUnescapedLiterals(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/café", testHandler)
+	_ = handler.Get("/a b", testHandler)
+	_ = handler.Get("/menú/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "id")))
+	})
+	_ = handler.Get("/tags/{tag} list", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "tag")))
+	})
+	_ = handler.Exact(http.MethodGet, "/exact a b", testHandler)
+	_ = handler.Exact(http.MethodGet, "/exáct", testHandler)
+
+	tests := []struct {
+		uri, body string
+	}{
+		{"/caf%C3%A9", "test_"},
+		{"/café", "test_"},
+		{"/a%20b", "test_"},
+		{"/men%C3%BA/3", "3"},
+		{"/men%C3%BA/caf%C3%A9", "café"},
+		{"/men%C3%BA/a%2Fb", "a/b"},
+		{"/men%C3%BA/100%25", "100%"},
+		{"/tags/go%20list", "go"},
+		{"/exact%20a%20b", "test_"},
+		{"/ex%C3%A1ct", "test_"},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, test.uri, nil))
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected 200 for '%s', got %d", test.uri, res.Code)
+		}
+		if body := res.Body.String(); body != test.body {
+			t.Fatalf("expected '%s' for '%s', got '%s'", test.body, test.uri, body)
+		}
+	}
+
+	// the method not allowed responses and the preflights use the same path
+	notAllowed := NewHandler(&Config{CORS: true})
+	_ = notAllowed.Get("/café", testHandler, WithMethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	res := httptest.NewRecorder()
+	notAllowed.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/caf%C3%A9", nil))
+	if res.Code != http.StatusTeapot {
+		t.Fatalf("expected 418, got %d", res.Code)
+	}
+	req := httptest.NewRequest(http.MethodOptions, "/caf%C3%A9", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	res = httptest.NewRecorder()
+	notAllowed.ServeHTTP(res, req)
+	if methods := res.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(methods, http.MethodGet) {
+		t.Fatalf("expected GET in allowed methods, got '%s'", methods)
+	}
+}
+
+func TestAnchoredMatch(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{id}", testHandler)
+	_ = handler.Get("/files/{name:.+}", testHandler)
+	_ = handler.Get("/assets/{path...}", testHandler, WithMethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	users, _ := handler.registered(http.MethodGet, "/users/{id}")
+	files, _ := handler.registered(http.MethodGet, "/files/{name:.+}")
+	assets, _ := handler.registered(http.MethodGet, "/assets/{path...}")
+
+	// the regexes must not match a request URI that ends with the path
+	if users.rgx.MatchString("/evil/users/123") {
+		t.Fatal("expected '/evil/users/123' not to match the regex")
+	}
+	if files.rgx.MatchString("/evil/files/a/b") {
+		t.Fatal("expected '/evil/files/a/b' not to match the regex")
+	}
+	if users.match("/evil/users/123") {
+		t.Fatal("expected '/evil/users/123' not to match")
+	}
+	if _, ok := users.decodeArgs("/evil/users/123"); ok {
+		t.Fatal("expected '/evil/users/123' not to decode")
+	}
+	if !users.match("/users/123") {
+		t.Fatal("expected '/users/123' to match")
+	}
+	// the depth of the catch-all routes is not limited, so only the anchor
+	// rejects the crafted request URIs
+	if assets.match("/evil/assets/app.js") {
+		t.Fatal("expected '/evil/assets/app.js' not to match")
+	}
+
+	// the method not allowed handler of the catch-all route is only used for
+	// its own request URIs
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/assets/app.js", nil))
+	if res.Code != http.StatusTeapot {
+		t.Fatalf("expected 418, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/evil/assets/app.js", nil))
+	if res.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", res.Code)
+	}
+}
+
+func TestDecodeArgsNamedOnly(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{id}", testHandler)
+	_ = handler.Get("/items/{kind:(book|disc)}", testHandler)
+
+	r, _ := handler.find(http.MethodGet, "/users/123")
+	args, ok := r.decodeArgs("/users/123")
+	if !ok || len(args) != 1 || args["id"] != "123" {
+		t.Fatalf("expected only the 'id' argument, got %v", args)
+	}
+	// the unnamed groups of the constraints are skipped too
+	r, _ = handler.find(http.MethodGet, "/items/book")
+	args, ok = r.decodeArgs("/items/book")
+	if !ok || len(args) != 1 || args["kind"] != "book" {
+		t.Fatalf("expected only the 'kind' argument, got %v", args)
+	}
+}
+
+// benchmarkCases function returns the handlers and request URIs covered by
+// the matching benchmarks: a static route, a route with a single argument and
+// a dynamic route of a table with many routes.
+func benchmarkCases(b *testing.B) []struct {
+	name    string
+	handler *Handler
+	uri     string
+} {
+	static := NewHandler(nil)
+	_ = static.Get("/api/v1/status", testHandler)
+	param := NewHandler(nil)
+	_ = param.Get("/api/v1/users/{id}", testHandler)
+	return []struct {
+		name    string
+		handler *Handler
+		uri     string
+	}{
+		{"static", static, "/api/v1/status"},
+		{"param", param, "/api/v1/users/123"},
+		{"many", benchmarkHandler(b), "/dynamic/249/123"},
+	}
+}
+
+func BenchmarkFind(b *testing.B) {
+	for _, bc := range benchmarkCases(b) {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, ok := bc.handler.find(http.MethodGet, bc.uri); !ok {
+					b.Fatal("expected route")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeArgs(b *testing.B) {
+	for _, bc := range benchmarkCases(b) {
+		r, _ := bc.handler.find(http.MethodGet, bc.uri)
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, ok := r.decodeArgs(bc.uri); !ok {
+					b.Fatal("expected arguments")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	for _, bc := range benchmarkCases(b) {
+		req := httptest.NewRequest(http.MethodGet, bc.uri, nil)
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bc.handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
+
+func TestFindAndDecode(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{id}", testHandler)
+	_ = handler.Get("/users/me", testHandler)
+	_ = handler.Exact(http.MethodGet, "/status", testHandler)
+
+	r, args, ok := handler.findAndDecode(http.MethodGet, "/users/123")
+	if !ok || r.path != "/users/{id}" || len(args) != 1 || args["id"] != "123" {
+		t.Fatalf("expected '/users/{id}' with id '123', got %v %v", ok, args)
+	}
+	// the registration order precedence is kept
+	if r, args, ok = handler.findAndDecode(http.MethodGet, "/users/me"); !ok || r.path != "/users/{id}" || args["id"] != "me" {
+		t.Fatalf("expected '/users/{id}' with id 'me', got %v %v", ok, args)
+	}
+	if r, args, ok = handler.findAndDecode(http.MethodGet, "/status"); !ok || r.path != "/status" || len(args) != 0 {
+		t.Fatalf("expected exact route '/status', got %v %v", ok, args)
+	}
+	if _, _, ok = handler.findAndDecode(http.MethodPost, "/users/123"); ok {
+		t.Fatal("expected no route for POST")
+	}
+	if _, _, ok = handler.findAndDecode(http.MethodGet, "/users/%ZZ"); ok {
+		t.Fatal("expected no route for a malformed argument")
+	}
+}
+
+func BenchmarkFindAndDecode(b *testing.B) {
+	for _, bc := range benchmarkCases(b) {
+		b.Run(bc.name+"/separated", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r, ok := bc.handler.find(http.MethodGet, bc.uri)
+				if !ok {
+					b.Fatal("expected route")
+				}
+				if _, ok := r.decodeArgs(bc.uri); !ok {
+					b.Fatal("expected arguments")
+				}
+			}
+		})
+		b.Run(bc.name+"/combined", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, ok := bc.handler.findAndDecode(http.MethodGet, bc.uri); !ok {
+					b.Fatal("expected route")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package apihandler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptedLanguage struct contains a language tag of an 'Accept-Language'
+// header and its quality value.
+type acceptedLanguage struct {
+	tag     string
+	quality float64
+}
+
+// parseAcceptLanguage function parses the provided 'Accept-Language' header
+// value and returns the language tags that it contains sorted by their quality
+// value. Tags with a quality value of zero are discarded.
+func parseAcceptLanguage(header string) []acceptedLanguage {
+	langs := []acceptedLanguage{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if quality > 0 {
+			langs = append(langs, acceptedLanguage{tag, quality})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].quality > langs[j].quality
+	})
+	return langs
+}
+
+// matchLocale function returns the best supported locale for the provided
+// 'Accept-Language' header value. It tries to match every accepted language
+// tag, by quality order, with a supported locale, first exactly and then by
+// their primary language subtag. If no tag matches, it returns the first
+// supported locale.
+func matchLocale(header string, supported []string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+	for _, lang := range parseAcceptLanguage(header) {
+		if lang.tag == "*" {
+			return supported[0]
+		}
+		for _, locale := range supported {
+			if strings.EqualFold(lang.tag, locale) {
+				return locale
+			}
+		}
+		base, _, _ := strings.Cut(lang.tag, "-")
+		for _, locale := range supported {
+			localeBase, _, _ := strings.Cut(locale, "-")
+			if strings.EqualFold(base, localeBase) {
+				return locale
+			}
+		}
+	}
+	return supported[0]
+}
+
+// Locale function returns a Middleware that selects the best locale of the
+// supported ones provided for every request, based on its 'Accept-Language'
+// header. The selected locale is stored in the request context and can be
+// read using LocaleFromContext. The first supported locale is used as default.
+func Locale(supported ...string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			locale := matchLocale(r.Header.Get("Accept-Language"), supported)
+			next(w, r.WithContext(context.WithValue(r.Context(), localeKey, locale)))
+		}
+	}
+}
+
+// LocaleFromContext function returns the locale selected by the Locale
+// middleware from the provided context. It returns an empty string if no
+// locale was selected.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey).(string)
+	return locale
+}
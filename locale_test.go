@@ -0,0 +1,38 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocale(t *testing.T) {
+	var locale string
+	handler := Locale("en", "es-ES", "fr")(func(w http.ResponseWriter, r *http.Request) {
+		locale = LocaleFromContext(r.Context())
+	})
+
+	tests := map[string]string{
+		"":                             "en",
+		"de":                           "en",
+		"fr":                           "fr",
+		"es-es":                        "es-ES",
+		"es-MX,es;q=0.9":               "es-ES",
+		"de;q=0.9, fr;q=0.5, en;q=0.7": "en",
+		"en;q=0, fr;q=0.1":             "fr",
+		"*":                            "en",
+	}
+	for header, expected := range tests {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.Header.Set("Accept-Language", header)
+		handler(httptest.NewRecorder(), req)
+		if locale != expected {
+			t.Fatalf("expected '%s' for '%s', got '%s'", expected, header, locale)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, testURI, nil)
+	if value := LocaleFromContext(req.Context()); value != "" {
+		t.Fatalf("expected empty locale, got '%s'", value)
+	}
+}
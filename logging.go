@@ -0,0 +1,105 @@
+package apihandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+)
+
+// LogLevelSilent constant is the log level that disables the access logs of a
+// route when it is set using WithLogLevel.
+const LogLevelSilent = slog.Level(math.MaxInt32)
+
+// AccessLog struct contains the information of a served request reported by
+// the Logging middleware, including the path template (Route) and the log
+// level of the route that served it, and the number of bytes of the response
+// body.
+type AccessLog struct {
+	Method   string
+	Path     string
+	Route    string
+	Status   int
+	Bytes    int64
+	Duration time.Duration
+	Level    slog.Level
+}
+
+// Logging function returns a Middleware that calls the provided function with
+// the AccessLog of every served request once the wrapped handler returns. The
+// response status is captured wrapping the http.ResponseWriter, so if the
+// handler writes the body without calling WriteHeader, or writes nothing, the
+// status recorded is the implicit 200 HTTP status sent by net/http. The log
+// level is read from the RouteContext of the request, so the middleware must
+// be registered using `Handler.Use` (or a Group) to support the levels defined
+// using WithLogLevel, otherwise slog.LevelInfo is used. The requests served by
+// routes with LogLevelSilent are not reported.
+func Logging(fn func(AccessLog)) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			route, _ := RequestRoute(r.Context())
+			if route.LogLevel == LogLevelSilent {
+				next(w, r)
+				return
+			}
+			start := time.Now()
+			rw := NewResponseRecorder(w)
+			next(rw, r)
+			fn(AccessLog{
+				Method:   r.Method,
+				Path:     r.URL.Path,
+				Route:    route.Path,
+				Status:   rw.Status(),
+				Bytes:    rw.BytesWritten(),
+				Duration: time.Since(start),
+				Level:    route.LogLevel,
+			})
+		}
+	}
+}
+
+// SlogAccessLog function returns a function to be used with the Logging
+// middleware that writes every AccessLog to the provided logger, at the level
+// of the route that served the request.
+func SlogAccessLog(logger *slog.Logger) func(AccessLog) {
+	return func(l AccessLog) {
+		logger.Log(context.Background(), l.Level, "request served",
+			slog.String("method", l.Method),
+			slog.String("path", l.Path),
+			slog.String("route", l.Route),
+			slog.Int("status", l.Status),
+			slog.Int64("bytes", l.Bytes),
+			slog.Duration("duration", l.Duration))
+	}
+}
+
+// FormatAccessLog function returns the default line written by the
+// LoggingMiddleware for the provided AccessLog, with the method, the path, the
+// route, the status, the response size and the duration of the request.
+func FormatAccessLog(l AccessLog) string {
+	return fmt.Sprintf("%s %s route=%q status=%d bytes=%d duration=%s",
+		l.Method, l.Path, l.Route, l.Status, l.Bytes, l.Duration)
+}
+
+// LoggerAccessLog function returns a function to be used with the Logging
+// middleware that writes every AccessLog to the provided logger, formatted
+// using the provided function or FormatAccessLog if it is nil.
+func LoggerAccessLog(logger *log.Logger, format func(AccessLog) string) func(AccessLog) {
+	if format == nil {
+		format = FormatAccessLog
+	}
+	return func(l AccessLog) {
+		logger.Println(format(l))
+	}
+}
+
+// LoggingMiddleware function returns a ready-to-use Logging middleware that
+// writes the access log of every request to the provided logger using
+// FormatAccessLog. To override the format, use the Logging middleware with
+// LoggerAccessLog and a custom format function.
+func LoggingMiddleware(logger *log.Logger) Middleware {
+	return Logging(LoggerAccessLog(logger, nil))
+}
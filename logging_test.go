@@ -0,0 +1,94 @@
+package apihandler
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogging(t *testing.T) {
+	var entry AccessLog
+	logger := Logging(func(l AccessLog) { entry = l })
+
+	handler := logger(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	if entry.Status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", entry.Status)
+	}
+	if entry.Method != http.MethodGet || entry.Path != testURI {
+		t.Fatalf("expected [%s] %s, got [%s] %s", http.MethodGet, testURI, entry.Method, entry.Path)
+	}
+
+	handler = logger(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, testURI, nil))
+	if entry.Status != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", entry.Status)
+	}
+
+	handler = logger(func(w http.ResponseWriter, r *http.Request) {})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	if entry.Status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", entry.Status)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	buf := new(bytes.Buffer)
+	handler := NewHandler(nil)
+	handler.Use(LoggingMiddleware(log.New(buf, "", 0)))
+	_ = handler.Get(testPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body"))
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	line := buf.String()
+	for _, expected := range []string{http.MethodGet, testURI, testPath, "status=200", "bytes=4"} {
+		if !strings.Contains(line, expected) {
+			t.Fatalf("expected '%s' in log, got '%s'", expected, line)
+		}
+	}
+
+	buf.Reset()
+	custom := Logging(LoggerAccessLog(log.New(buf, "", 0), func(l AccessLog) string {
+		return l.Method + "|" + l.Path
+	}))
+	custom(func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodPost, testURI, nil))
+	if line := buf.String(); line != http.MethodPost+"|"+testURI+"\n" {
+		t.Fatalf("expected custom format, got '%s'", line)
+	}
+}
+
+func TestWithLogLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	handler := NewHandler(nil)
+	handler.Use(Logging(SlogAccessLog(logger)))
+	_ = handler.Get("/health", testHandler, WithLogLevel(LogLevelSilent))
+	_ = handler.Get("/debug", testHandler, WithLogLevel(slog.LevelDebug))
+	_ = handler.Post(testPath, testHandler)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if buf.Len() != 0 {
+		t.Fatalf("expected no log line, got '%s'", buf.String())
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/debug", nil))
+	if line := buf.String(); !strings.Contains(line, "level=DEBUG") || !strings.Contains(line, "path=/debug") {
+		t.Fatalf("expected debug log line, got '%s'", line)
+	}
+
+	buf.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, testURI, nil))
+	if line := buf.String(); !strings.Contains(line, "level=INFO") || !strings.Contains(line, "status=200") {
+		t.Fatalf("expected info log line, got '%s'", line)
+	}
+}
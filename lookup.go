@@ -0,0 +1,84 @@
+package apihandler
+
+import "sort"
+
+// LookupStatus type defines the result of looking up a route for a request.
+type LookupStatus int
+
+const (
+	// LookupNotFound status means that no route matches the request path.
+	LookupNotFound LookupStatus = iota
+	// LookupMethodMismatch status means that there are routes that match the
+	// request path but none of them with the request method.
+	LookupMethodMismatch
+	// LookupMatched status means that a route matches the request method and
+	// path.
+	LookupMatched
+)
+
+// String method returns the name of the status.
+func (s LookupStatus) String() string {
+	switch s {
+	case LookupMatched:
+		return "Matched"
+	case LookupMethodMismatch:
+		return "MethodMismatch"
+	default:
+		return "NotFound"
+	}
+}
+
+// LookupResult struct contains the result of looking up a route for a request.
+// If the route is matched, it includes the route information and the decoded
+// arguments. If the method does not match, it includes the methods of the
+// routes that match the request path.
+type LookupResult struct {
+	Status  LookupStatus
+	Route   RouteInfo
+	Params  map[string]string
+	Methods []string
+}
+
+// Lookup method looks up the route that would serve a request with the method
+// and the path provided, without serving it, following the same rules that
+// `Handler.ServeHTTP`. If no route matches, the result status tells if there
+// are routes for the path with other methods (and which ones) or if the path
+// is not found at all.
+func (m *Handler) Lookup(method, path string) LookupResult {
+	if route, args, exist := m.findAndDecode(method, path); exist {
+		return LookupResult{
+			Status: LookupMatched,
+			Route:  RouteInfo{Method: route.method, Path: route.path},
+			Params: args,
+		}
+	}
+	if methods := m.methodsFor(path, true); len(methods) > 0 {
+		return LookupResult{Status: LookupMethodMismatch, Methods: methods}
+	}
+	return LookupResult{Status: LookupNotFound}
+}
+
+// methodsFor method returns the sorted list of methods of the enabled routes
+// that match the provided path. The implicit HEAD routes are only included if
+// the implicit flag provided is true.
+func (m *Handler) methodsFor(path string, implicit bool) []string {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	found := map[string]bool{}
+	for method, routes := range m.exact {
+		if r, ok := routes[path]; ok && !r.disabled && (implicit || !r.implicit) {
+			found[method] = true
+		}
+	}
+	for _, r := range m.routes {
+		if !r.disabled && (implicit || !r.implicit) && r.match(path) {
+			found[r.method] = true
+		}
+	}
+	methods := make([]string, 0, len(found))
+	for method := range found {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
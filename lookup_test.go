@@ -0,0 +1,39 @@
+package apihandler
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Post(testPath, testHandler)
+
+	result := handler.Lookup(http.MethodGet, testURI)
+	if result.Status != LookupMatched {
+		t.Fatalf("expected %s, got %s", LookupMatched, result.Status)
+	}
+	if result.Route.Method != http.MethodGet || result.Route.Path != testPath {
+		t.Fatalf("expected [%s] %s, got %+v", http.MethodGet, testPath, result.Route)
+	}
+	if result.Params["name"] != "args" {
+		t.Fatalf("expected 'args', got '%s'", result.Params["name"])
+	}
+
+	result = handler.Lookup(http.MethodDelete, testURI)
+	if result.Status != LookupMethodMismatch {
+		t.Fatalf("expected %s, got %s", LookupMethodMismatch, result.Status)
+	}
+	if len(result.Methods) != 3 || result.Methods[0] != http.MethodGet || result.Methods[1] != http.MethodHead || result.Methods[2] != http.MethodPost {
+		t.Fatalf("expected [GET HEAD POST], got %v", result.Methods)
+	}
+
+	result = handler.Lookup(http.MethodGet, "/unknown")
+	if result.Status != LookupNotFound {
+		t.Fatalf("expected %s, got %s", LookupNotFound, result.Status)
+	}
+	if len(result.Methods) != 0 {
+		t.Fatalf("expected no methods, got %v", result.Methods)
+	}
+}
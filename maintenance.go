@@ -0,0 +1,57 @@
+package apihandler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maintenanceMode struct contains the response sent to every request while
+// the maintenance mode of the Handler is enabled.
+type maintenanceMode struct {
+	body       []byte
+	retryAfter time.Duration
+}
+
+// serve method writes the maintenance response, a 503 HTTP error with the
+// maintenance body and the 'Retry-After' header if it is defined.
+func (mm *maintenanceMode) serve(res http.ResponseWriter) {
+	if mm.retryAfter > 0 {
+		seconds := int(math.Ceil(mm.retryAfter.Seconds()))
+		res.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	if len(mm.body) == 0 {
+		http.Error(res, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	if res.Header().Get("Content-Type") == "" {
+		res.Header().Set("Content-Type", http.DetectContentType(mm.body))
+	}
+	res.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = res.Write(mm.body)
+}
+
+// SetMaintenance method enables or disables the maintenance mode of the
+// Handler at runtime. While it is enabled, every request is responded with a
+// 503 HTTP error with the provided body (or the default status text if it is
+// empty) and the 'Retry-After' header set to the provided duration, if it is
+// positive. The paths defined in Config.MaintenanceExemptPaths are served as
+// usual.
+func (m *Handler) SetMaintenance(on bool, body []byte, retryAfter time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if !on {
+		m.maintenance = nil
+		return
+	}
+	m.maintenance = &maintenanceMode{body: body, retryAfter: retryAfter}
+}
+
+// maintenanceMode method returns the current maintenance mode of the Handler,
+// or nil if it is disabled.
+func (m *Handler) maintenanceMode() *maintenanceMode {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.maintenance
+}
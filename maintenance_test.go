@@ -0,0 +1,43 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetMaintenance(t *testing.T) {
+	handler := NewHandler(&Config{MaintenanceExemptPaths: []string{"/health"}})
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Get("/health", testHandler)
+
+	handler.SetMaintenance(true, []byte("back soon"), 1500*time.Millisecond)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "back soon" {
+		t.Fatalf("expected 'back soon', got '%s'", body)
+	}
+	if value := res.Header().Get("Retry-After"); value != "2" {
+		t.Fatalf("expected '2', got '%s'", value)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	handler.SetMaintenance(false, nil, 0)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "test_args" {
+		t.Fatalf("expected 'test_args', got '%s'", body)
+	}
+}
@@ -0,0 +1,73 @@
+package apihandler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Merge method copies the routes of the provided Handler into the current
+// one, including its exact routes. The middlewares, the fallbacks and the
+// config of the provided Handler are not merged. If any route of the provided
+// Handler has the same method and path than a route of the current one, or a
+// different path that matches the same request URIs (as `Handler.HandleFunc`
+// checks), no route is merged and an error wrapping ErrRouteConflict with the
+// list of conflicting routes is returned. The implicit HEAD routes never
+// conflict: they are only merged if there is no route for the same path.
+func (m *Handler) Merge(other *Handler) error {
+	other.mtx.RLock()
+	routes := make([]route, 0, len(other.routes))
+	for _, r := range other.routes {
+		routes = append(routes, *r)
+	}
+	exact := []route{}
+	for _, paths := range other.exact {
+		for _, r := range paths {
+			exact = append(exact, *r)
+		}
+	}
+	other.mtx.RUnlock()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	conflicts := []string{}
+	for _, r := range routes {
+		if r.implicit {
+			continue
+		}
+		if current, ok := m.registered(r.method, r.path); ok && !current.implicit {
+			conflicts = append(conflicts, fmt.Sprintf("[%s] %s", r.method, r.path))
+		} else if current, ok := m.conflicting(&r); ok {
+			conflicts = append(conflicts, fmt.Sprintf("[%s] %s with %s", r.method, r.path, current.path))
+		}
+	}
+	for _, r := range exact {
+		if current, ok := m.exact[r.method][r.path]; ok && !r.implicit && !current.implicit {
+			conflicts = append(conflicts, fmt.Sprintf("[%s] %s", r.method, r.path))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: %s", ErrRouteConflict, strings.Join(conflicts, ", "))
+	}
+	for i := range routes {
+		r := &routes[i]
+		if _, ok := m.registered(r.method, r.path); ok && r.implicit {
+			continue
+		}
+		if r.method == http.MethodHead && !r.implicit {
+			m.replaceImplicit(r)
+		}
+		m.register(r)
+	}
+	for i := range exact {
+		r := &exact[i]
+		if _, ok := m.exact[r.method][r.path]; ok && r.implicit {
+			continue
+		}
+		if _, ok := m.exact[r.method]; !ok {
+			m.exact[r.method] = map[string]*route{}
+		}
+		m.exact[r.method][r.path] = r
+	}
+	return nil
+}
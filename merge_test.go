@@ -0,0 +1,66 @@
+package apihandler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	users := NewHandler(nil)
+	_ = users.Get("/users/{id}", testHandler)
+	_ = users.Exact(http.MethodGet, "/users/me", testHandler)
+	orders := NewHandler(nil)
+	_ = orders.Get("/orders/{id}", testHandler)
+	_ = orders.Head("/users/{id}", testHandler)
+
+	handler := NewHandler(nil)
+	if err := handler.Merge(users); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	// the explicit HEAD route overwrites the implicit one
+	if err := handler.Merge(orders); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	for _, uri := range []string{"/users/args", "/users/me", "/orders/args"} {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", uri, res.Code)
+		}
+	}
+	if r, _ := handler.find(http.MethodHead, "/users/args"); r.implicit {
+		t.Fatal("expected explicit HEAD route")
+	}
+
+	conflicting := NewHandler(nil)
+	_ = conflicting.Get("/users/{id}", testHandler)
+	_ = conflicting.Post("/new", testHandler)
+	err := handler.Merge(conflicting)
+	if !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected ErrRouteConflict, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "[GET] /users/{id}") {
+		t.Fatalf("expected conflicting route in error, got %s", err)
+	}
+	if _, exist := handler.find(http.MethodPost, "/new"); exist {
+		t.Fatal("expected no route merged after a conflict")
+	}
+
+	// the routes that match the same request URIs also conflict
+	shadowed := NewHandler(nil)
+	_ = shadowed.Get("/users/{name}", testHandler)
+	_ = shadowed.Post("/new", testHandler)
+	err = handler.Merge(shadowed)
+	if !errors.Is(err, ErrRouteConflict) {
+		t.Fatalf("expected ErrRouteConflict, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "[GET] /users/{name} with /users/{id}") {
+		t.Fatalf("expected conflicting route in error, got %s", err)
+	}
+	if _, exist := handler.find(http.MethodPost, "/new"); exist {
+		t.Fatal("expected no route merged after a conflict")
+	}
+}
@@ -0,0 +1,146 @@
+package apihandler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"runtime/debug"
+)
+
+// RequestIDHeader constant contains the name of the header used by the
+// RequestID middleware to read and write the request identifier.
+const RequestIDHeader = "X-Request-ID"
+
+// ServedByHeader constant contains the name of the header used by the
+// InstanceID middleware to write the identifier of the server instance.
+const ServedByHeader = "X-Served-By"
+
+// Middleware type defines a function that wraps an http.HandlerFunc to run
+// some logic before and/or after the wrapped handler.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// chain function wraps the provided handler with the provided middlewares,
+// the first middleware being the outermost layer.
+func chain(handler http.HandlerFunc, mws ...Middleware) http.HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// DefaultMiddleware function returns the recommended stack of middlewares,
+// in order, for the provided config: request identifiers, access logs written
+// to Config.AccessLogger (or the standard logger) using LoggingMiddleware, and
+// panic recovery (unless the Handler already recovers them because
+// Config.Recover or Config.RecoverHandler are defined). The access logs wrap
+// the recovery, so the recovered panics are logged with their 500 HTTP
+// status. CORS and rate limiting are already applied by the Handler based on
+// the same config.
+func DefaultMiddleware(cfg *Config) []Middleware {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	logger := cfg.AccessLogger
+	if logger == nil {
+		logger = log.Default()
+	}
+	mws := []Middleware{RequestID(), LoggingMiddleware(logger)}
+	if !cfg.Recover && cfg.RecoverHandler == nil {
+		mws = append(mws, Recovery())
+	}
+	return mws
+}
+
+// Recovery function returns a Middleware that recovers the wrapped handler
+// from panics, logging the panic and its stack trace with the standard logger
+// and responding with a 500 HTTP error, unless the handler already started
+// its response. The http.ErrAbortHandler panics are not recovered, so
+// net/http can abort the response as intended.
+func Recovery() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				if rec := recover(); rec != nil {
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+					log.Printf("%s on '%s': %v\n%s", ErrHandlerPanic, r.URL.Path, rec, debug.Stack())
+					if !rw.written() {
+						http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					}
+				}
+			}()
+			next(rw, r)
+		}
+	}
+}
+
+// RequestID function returns a Middleware that assigns an identifier to every
+// request, reusing the one received in the 'X-Request-ID' header or generating
+// a random one. The identifier is set in the 'X-Request-ID' response header
+// and stored in the request context, where it can be read using
+// RequestIDFromContext.
+func RequestID() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				buf := make([]byte, 16)
+				if _, err := rand.Read(buf); err == nil {
+					id = hex.EncodeToString(buf)
+				}
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		}
+	}
+}
+
+// RequestIDFromContext function returns the request identifier assigned by
+// the RequestID middleware from the provided context. It returns an empty
+// string if no identifier was assigned.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// InstanceID function returns a Middleware that sets the provided server
+// instance identifier in the 'X-Served-By' response header of every request,
+// to know which instance of a load-balanced fleet served it. If the provided
+// identifier is empty, the hostname of the machine is used.
+func InstanceID(id string) Middleware {
+	if id == "" {
+		id, _ = os.Hostname()
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(ServedByHeader, id)
+			next(w, r)
+		}
+	}
+}
+
+// RequireJSON function returns a Middleware that rejects POST, PUT and PATCH
+// requests with a 415 HTTP error if their 'Content-Type' is not
+// 'application/json'. The content type params (like charset) are ignored.
+// Requests with other methods are passed through without checking.
+func RequireJSON() Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+				mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil || mediaType != "application/json" {
+					http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+					return
+				}
+			}
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package apihandler
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequireJSON(t *testing.T) {
+	handler := RequireJSON()(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, testURI, strings.NewReader(`{"name":"test"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, testURI, strings.NewReader("name=test"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, testURI, nil)
+	res = httptest.NewRecorder()
+	handler(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	logs := &bytes.Buffer{}
+	log.SetOutput(logs)
+	defer log.SetOutput(os.Stderr)
+
+	handler := Recovery()(func(w http.ResponseWriter, r *http.Request) {
+		panic("something went wrong")
+	})
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", res.Code)
+	}
+	if line := logs.String(); !strings.Contains(line, "something went wrong") || !strings.Contains(line, "TestRecovery") {
+		t.Fatalf("expected the panic and its stack trace logged, got '%s'", line)
+	}
+
+	// the started responses are not modified
+	handler = Recovery()(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		panic("something went wrong")
+	})
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusOK || res.Body.String() != "partial" {
+		t.Fatalf("expected 200 with 'partial', got %d '%s'", res.Code, res.Body.String())
+	}
+
+	// the aborted handlers are propagated
+	handler = Recovery()(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler, got %v", rec)
+		}
+	}()
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	t.Fatal("expected the panic to be propagated")
+}
+
+func TestDefaultMiddleware(t *testing.T) {
+	if mws := DefaultMiddleware(&Config{Recover: true}); len(mws) != 2 {
+		t.Fatalf("expected 2 middlewares, got %d", len(mws))
+	}
+
+	var requestID string
+	handler := NewHandler(nil)
+	_ = handler.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		requestID = RequestIDFromContext(r.Context())
+		panic("something went wrong")
+	})
+	logs := &bytes.Buffer{}
+	server := chain(handler.ServeHTTP, DefaultMiddleware(&Config{AccessLogger: log.New(logs, "", 0)})...)
+
+	res := httptest.NewRecorder()
+	server(res, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", res.Code)
+	}
+	if line := logs.String(); !strings.HasPrefix(line, "GET /panic ") || !strings.Contains(line, "status=500") {
+		t.Fatalf("expected access log of the recovered request, got '%s'", line)
+	}
+	if value := res.Header().Get(RequestIDHeader); value == "" || value != requestID {
+		t.Fatalf("expected request id '%s', got '%s'", requestID, value)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(RequestIDHeader, "test-id")
+	res = httptest.NewRecorder()
+	server(res, req)
+	if value := res.Header().Get(RequestIDHeader); value != "test-id" {
+		t.Fatalf("expected 'test-id', got '%s'", value)
+	}
+}
+
+func TestInstanceID(t *testing.T) {
+	handler := InstanceID("instance-1")(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if value := res.Header().Get(ServedByHeader); value != "instance-1" {
+		t.Fatalf("expected 'instance-1', got '%s'", value)
+	}
+
+	hostname, _ := os.Hostname()
+	handler = InstanceID("")(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if value := res.Header().Get(ServedByHeader); value != hostname {
+		t.Fatalf("expected '%s', got '%s'", hostname, value)
+	}
+}
+
+func TestHandlerUse(t *testing.T) {
+	calls := []string{}
+	mw := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+	handler := NewHandler(nil)
+	handler.Use(mw("first"), mw("second"))
+	handler.Use(mw("third"))
+	_ = handler.Get(testPath, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	expected := []string{"first", "second", "third", "handler"}
+	if strings.Join(calls, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %v, got %v", expected, calls)
+	}
+
+	// the middlewares are not applied if no route matches
+	calls = []string{}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	if len(calls) != 0 {
+		t.Fatalf("expected no calls, got %v", calls)
+	}
+}
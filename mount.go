@@ -0,0 +1,74 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// mount struct contains a sub-Handler mounted at a path prefix of a Handler.
+type mount struct {
+	prefix string
+	sub    *Handler
+}
+
+// Mount method mounts the provided sub-Handler at the provided path prefix.
+// The requests whose path is the prefix or starts with the prefix followed by
+// a slash are delegated to the sub-Handler after the maintenance mode, the
+// rate limiter and the CORS headers of the current Handler, removing the
+// prefix from the request path, so the routes of the sub-Handler match
+// against the trimmed path. If more than one prefix matches, the longest one
+// is used. The mounted prefixes take precedence over the routes registered
+// in the current Handler.
+func (m *Handler) Mount(prefix string, sub *Handler) {
+	prefix = strings.TrimSuffix(prefix, uriSeparator)
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for i, mnt := range m.mounts {
+		if mnt.prefix == prefix {
+			m.mounts[i].sub = sub
+			return
+		}
+	}
+	m.mounts = append(m.mounts, mount{prefix: prefix, sub: sub})
+	sort.SliceStable(m.mounts, func(i, j int) bool {
+		return len(m.mounts[i].prefix) > len(m.mounts[j].prefix)
+	})
+}
+
+// mountFor method returns the mount with the longest prefix of the provided
+// path and the path without that prefix. It returns false if no sub-Handler
+// is mounted for the path.
+func (m *Handler) mountFor(path string) (mount, string, bool) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	for _, mnt := range m.mounts {
+		if rest, ok := strings.CutPrefix(path, mnt.prefix); ok && (rest == "" || strings.HasPrefix(rest, uriSeparator)) {
+			if rest == "" {
+				rest = uriSeparator
+			}
+			return mnt, rest, true
+		}
+	}
+	return mount{}, "", false
+}
+
+// stripPrefix function returns a shallow copy of the provided request whose
+// URL path is replaced by the provided one, removing also the provided prefix
+// from the raw (escaped) path if it is defined, as `http.StripPrefix` does.
+func stripPrefix(req *http.Request, prefix, path string) *http.Request {
+	stripped := new(http.Request)
+	*stripped = *req
+	stripped.URL = new(url.URL)
+	*stripped.URL = *req.URL
+	stripped.URL.Path = path
+	stripped.URL.RawPath = ""
+	if rawPath, ok := strings.CutPrefix(req.URL.RawPath, prefix); ok && req.URL.RawPath != "" {
+		if rawPath == "" {
+			rawPath = uriSeparator
+		}
+		stripped.URL.RawPath = rawPath
+	}
+	return stripped
+}
@@ -0,0 +1,89 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	admin := NewHandler(nil)
+	_ = admin.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("admin " + r.Header.Get("id") + " " + r.URL.Path))
+	})
+	_ = admin.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("admin index"))
+	})
+	public := NewHandler(nil)
+	_ = public.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("public " + r.Header.Get("id")))
+	})
+
+	handler := NewHandler(&Config{CORS: true, RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 4}})
+	_ = handler.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("root " + r.Header.Get("id")))
+	})
+	handler.Mount("/admin/", admin)
+	handler.Mount("/public", public)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve("/admin/users/42")
+	if res.Code != http.StatusOK || res.Body.String() != "admin 42 /users/42" {
+		t.Fatalf("expected 'admin 42 /users/42', got %d %q", res.Code, res.Body.String())
+	}
+	// the CORS headers of the parent are set before delegating
+	if res.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Fatalf("expected the CORS headers of the parent")
+	}
+	// the escaped path is also stripped
+	if res = serve("/admin/users/a%2Fb"); res.Body.String() != "admin a/b /users/a/b" {
+		t.Fatalf("expected 'admin a/b /users/a/b', got %q", res.Body.String())
+	}
+	if res = serve("/public/users/7"); res.Body.String() != "public 7" {
+		t.Fatalf("expected 'public 7', got %q", res.Body.String())
+	}
+	if res = serve("/admin"); res.Body.String() != "admin index" {
+		t.Fatalf("expected 'admin index', got %q", res.Body.String())
+	}
+	// the rate limiter of the parent runs before delegating
+	if res = serve("/admin/users/42"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", res.Code)
+	}
+
+	// paths that only share the prefix characters are not delegated
+	handler = NewHandler(nil)
+	_ = handler.Get("/administrator", testHandler)
+	handler.Mount("/admin", admin)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/administrator", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestMountLongestPrefix(t *testing.T) {
+	api := NewHandler(nil)
+	_ = api.Get("/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("api"))
+	})
+	v1 := NewHandler(nil)
+	_ = v1.Get("/items", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("v1"))
+	})
+	handler := NewHandler(nil)
+	handler.Mount("/api", api)
+	handler.Mount("/api/v1", v1)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/v1/items", nil))
+	if res.Body.String() != "v1" {
+		t.Fatalf("expected 'v1', got %q", res.Body.String())
+	}
+}
@@ -0,0 +1,178 @@
+package apihandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// openAPIVersion constant contains the version of the OpenAPI specification
+// of the documents generated by `Handler.OpenAPI`.
+const openAPIVersion = "3.0.3"
+
+// OpenAPIInfo struct contains the metadata of the API included in the info
+// object of the OpenAPI document generated by `Handler.OpenAPI`.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// openAPIDocument struct is the root object of an OpenAPI document, whose
+// paths map the path templates to their operations by method.
+type openAPIDocument struct {
+	OpenAPI string                                 `json:"openapi"`
+	Info    OpenAPIInfo                            `json:"info"`
+	Paths   map[string]map[string]openAPIOperation `json:"paths"`
+}
+
+// openAPIOperation struct is an operation of an OpenAPI path item. The
+// responses are required by the specification, so a default one is always
+// included.
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+// openAPIParameter struct is a path parameter of an OpenAPI operation.
+type openAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   openAPISchema `json:"schema"`
+}
+
+// openAPISchema struct is the schema of an OpenAPI path parameter, including
+// the constraint of its argument as pattern.
+type openAPISchema struct {
+	Type    string `json:"type"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// openAPIResponse struct is a response of an OpenAPI operation.
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// OpenAPI method returns a JSON encoded OpenAPI 3.0 document that describes
+// the enabled routes registered in the Handler, with the provided info. Every
+// route path becomes a path item, whose named arguments become path
+// parameters (with their constraints as patterns), and every method of the
+// path becomes an operation, identified by the route name assigned using
+// WithRouteName and grouped by the route tags. The routes that end with an
+// optional argument are described with and without it, since the path
+// parameters are always required, and the operation id of the path without
+// the optional argument, as the ids reused by several routes, gets a numeric
+// suffix to keep them unique. The implicit HEAD routes are not included.
+func (m *Handler) OpenAPI(info OpenAPIInfo) ([]byte, error) {
+	m.mtx.RLock()
+	routes := []*route{}
+	for _, r := range m.routes {
+		if !r.implicit && !r.disabled {
+			routes = append(routes, r)
+		}
+	}
+	for _, paths := range m.exact {
+		for _, r := range paths {
+			if !r.implicit && !r.disabled {
+				routes = append(routes, r)
+			}
+		}
+	}
+	m.mtx.RUnlock()
+
+	doc := openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    info,
+		Paths:   map[string]map[string]openAPIOperation{},
+	}
+	operationIDs := map[string]bool{}
+	for _, r := range routes {
+		templates := []string{r.path}
+		if !r.exact {
+			templates = openAPIPaths(r.path)
+		}
+		for _, template := range templates {
+			path, params := template, []openAPIParameter{}
+			if !r.exact {
+				path, params = openAPIPath(template)
+			}
+			if _, ok := doc.Paths[path]; !ok {
+				doc.Paths[path] = map[string]openAPIOperation{}
+			}
+			doc.Paths[path][strings.ToLower(r.method)] = openAPIOperation{
+				OperationID: uniqueOperationID(operationIDs, r.routeName),
+				Tags:        r.tags,
+				Parameters:  params,
+				Responses: map[string]openAPIResponse{
+					"default": {Description: "Default response"},
+				},
+			}
+		}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding OpenAPI document: %w", err)
+	}
+	return data, nil
+}
+
+// uniqueOperationID function returns the provided operation id, or the id
+// with a numeric suffix (e.g. 'getUser_2') if it was already returned, as
+// recorded in the provided map, since the OpenAPI operation ids must be
+// unique. The empty ids are returned as they are.
+func uniqueOperationID(used map[string]bool, id string) string {
+	if id == "" {
+		return ""
+	}
+	unique := id
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s_%d", id, n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// openAPIPaths function returns the route paths to describe for the provided
+// one: the path itself and, if it ends with an optional argument, the path
+// without its last segment, in that order.
+func openAPIPaths(path string) []string {
+	loc := optionalArgToRgx.FindStringIndex(path)
+	if loc == nil {
+		return []string{path}
+	}
+	base := path[:loc[0]]
+	if base == "" {
+		base = uriSeparator
+	}
+	return []string{path, base}
+}
+
+// openAPIPath function returns the OpenAPI path template of the provided
+// route path, removing the constraints and the optional and catch-all markers
+// of its named arguments, and the path parameters of these arguments.
+func openAPIPath(path string) (string, []openAPIParameter) {
+	var template strings.Builder
+	params := []openAPIParameter{}
+	for {
+		start, end, ok := nextArg(path)
+		if !ok {
+			break
+		}
+		arg := path[start+1 : end]
+		name, constraint, _ := strings.Cut(arg, ":")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "?"), "...")
+		template.WriteString(path[:start])
+		template.WriteString("{" + name + "}")
+		param := openAPIParameter{Name: name, In: "path", Required: true, Schema: openAPISchema{Type: "string"}}
+		if constraint != "" {
+			param.Schema.Pattern = "^(?:" + constraint + ")$"
+		}
+		params = append(params, param)
+		path = path[end+1:]
+	}
+	template.WriteString(path)
+	return template.String(), params
+}
@@ -0,0 +1,103 @@
+package apihandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users", testHandler, WithRouteName("listUsers"), WithTags("users"))
+	_ = handler.Post("/users", testHandler)
+	_ = handler.Get("/users/{id:[0-9]+}", testHandler, WithRouteName("getUser"))
+	_ = handler.Get("/list/{page?}", testHandler, WithRouteName("list"))
+	_ = handler.Get("/lists/{id}", testHandler, WithRouteName("list"))
+	_ = handler.Get("/files/{bucket}/{path...}", testHandler)
+	_ = handler.Get("/disabled", testHandler)
+	handler.DisableRoute(http.MethodGet, "/disabled")
+
+	data, err := handler.OpenAPI(OpenAPIInfo{Title: "Test API", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if doc.OpenAPI != openAPIVersion || doc.Info.Title != "Test API" || doc.Info.Version != "1.0.0" {
+		t.Fatalf("expected OpenAPI %s document of 'Test API' 1.0.0, got %+v", openAPIVersion, doc)
+	}
+
+	expectedMethods := map[string][]string{
+		"/users":                 {"get", "post"},
+		"/users/{id}":            {"get"},
+		"/list":                  {"get"},
+		"/list/{page}":           {"get"},
+		"/lists/{id}":            {"get"},
+		"/files/{bucket}/{path}": {"get"},
+	}
+	if len(doc.Paths) != len(expectedMethods) {
+		t.Fatalf("expected %d paths, got %v", len(expectedMethods), doc.Paths)
+	}
+	for path, methods := range expectedMethods {
+		item, ok := doc.Paths[path]
+		if !ok {
+			t.Fatalf("expected path '%s', got %v", path, doc.Paths)
+		}
+		if len(item) != len(methods) {
+			t.Fatalf("expected methods %v for '%s', got %v", methods, path, item)
+		}
+		for _, method := range methods {
+			if _, ok := item[method]; !ok {
+				t.Fatalf("expected method '%s' for '%s', got %v", method, path, item)
+			}
+		}
+	}
+
+	// the operation ids are unique, including the ones of the paths without
+	// the optional arguments and the reused route names
+	operationIDs := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method, operation := range item {
+			if operation.OperationID == "" {
+				continue
+			}
+			if operationIDs[operation.OperationID] {
+				t.Fatalf("expected unique operation ids, got '%s' again for %s %s", operation.OperationID, method, path)
+			}
+			operationIDs[operation.OperationID] = true
+		}
+	}
+	if id := doc.Paths["/list/{page}"]["get"].OperationID; id != "list" {
+		t.Fatalf("expected 'list', got '%s'", id)
+	}
+	if len(operationIDs) != 5 {
+		t.Fatalf("expected 5 operation ids, got %v", operationIDs)
+	}
+
+	listUsers := doc.Paths["/users"]["get"]
+	if listUsers.OperationID != "listUsers" || !reflect.DeepEqual(listUsers.Tags, []string{"users"}) {
+		t.Fatalf("expected 'listUsers' operation with 'users' tag, got %+v", listUsers)
+	}
+	if _, ok := listUsers.Responses["default"]; !ok {
+		t.Fatalf("expected default response, got %v", listUsers.Responses)
+	}
+	expectedParams := []openAPIParameter{
+		{Name: "id", In: "path", Required: true, Schema: openAPISchema{Type: "string", Pattern: "^(?:[0-9]+)$"}},
+	}
+	if params := doc.Paths["/users/{id}"]["get"].Parameters; !reflect.DeepEqual(params, expectedParams) {
+		t.Fatalf("expected %v, got %v", expectedParams, params)
+	}
+	expectedParams = []openAPIParameter{
+		{Name: "bucket", In: "path", Required: true, Schema: openAPISchema{Type: "string"}},
+		{Name: "path", In: "path", Required: true, Schema: openAPISchema{Type: "string"}},
+	}
+	if params := doc.Paths["/files/{bucket}/{path}"]["get"].Parameters; !reflect.DeepEqual(params, expectedParams) {
+		t.Fatalf("expected %v, got %v", expectedParams, params)
+	}
+	if params := doc.Paths["/list"]["get"].Parameters; len(params) != 0 {
+		t.Fatalf("expected no parameters, got %v", params)
+	}
+}
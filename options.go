@@ -0,0 +1,95 @@
+package apihandler
+
+import "net/http"
+
+// Option interface defines a parameter of the Handler config that can be
+// provided to NewHandler. Both the *Config and the functional options (like
+// WithCORS or WithRateLimit) implement it.
+type Option interface {
+	apply(*Config)
+}
+
+// optionFunc type adapts a function that modifies the Handler config to the
+// Option interface.
+type optionFunc func(*Config)
+
+// apply method calls the adapted function with the provided config.
+func (fn optionFunc) apply(cfg *Config) {
+	fn(cfg)
+}
+
+// apply method replaces the provided config with the current one, so a
+// *Config can be provided to NewHandler as an Option. A nil config is
+// ignored.
+func (c *Config) apply(cfg *Config) {
+	if c != nil {
+		*cfg = *c
+	}
+}
+
+// WithCORS function returns an Option that enables or disables the CORS
+// headers of the Handler.
+func WithCORS(enabled bool) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.CORS = enabled
+	})
+}
+
+// WithRateLimit function returns an Option that enables the rate limiter of
+// the Handler with the provided rate (requests per second) and burst size.
+func WithRateLimit(rate float64, burst int) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.RateLimitConfig = &RateLimitConfig{Rate: rate, Limit: burst}
+	})
+}
+
+// WithLimiterStore function returns an Option that replaces the default
+// in-memory storage of the rate limiters with the provided one. It is only
+// used if the rate limiter is enabled.
+func WithLimiterStore(store LimiterStore) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.LimiterStore = store
+	})
+}
+
+// WithNotFound function returns an Option that assigns the provided handler
+// to the requests that do not match any route.
+func WithNotFound(h http.HandlerFunc) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.NotFound = h
+	})
+}
+
+// WithRecover function returns an Option that enables the recovery of the
+// panics raised by the route handlers, responding with the provided handler or
+// with a 500 HTTP error if it is nil.
+func WithRecover(h func(w http.ResponseWriter, r *http.Request, rec any)) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.Recover = true
+		cfg.RecoverHandler = h
+	})
+}
+
+// WithTrustedProxies function returns an Option that defines the IP addresses
+// and CIDR ranges of the proxies whose forwarded headers are trusted.
+func WithTrustedProxies(proxies ...string) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.TrustedProxies = proxies
+	})
+}
+
+// WithEncoder function returns an Option that replaces the default JSON
+// encoder of the values returned by the ValueHandlers.
+func WithEncoder(encoder Encoder) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.Encoder = encoder
+	})
+}
+
+// WithRateLimitExempt function returns an Option that defines the IP addresses
+// and CIDR ranges of the clients that are never rate limited.
+func WithRateLimitExempt(clients ...string) Option {
+	return optionFunc(func(cfg *Config) {
+		cfg.RateLimitExempt = clients
+	})
+}
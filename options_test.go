@@ -0,0 +1,83 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHandlerOptions(t *testing.T) {
+	notFound := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}
+	handler := NewHandler(
+		WithCORS(true),
+		WithRateLimit(0.001, 1),
+		WithNotFound(notFound),
+		WithRecover(nil),
+		WithTrustedProxies("10.0.0.0/8"),
+	)
+	_ = handler.Get(testPath, testHandler)
+	_ = handler.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	serve := func(path, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = addr
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	// cors
+	res := serve(testURI, "10.0.0.1:1234")
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if res.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("expected CORS headers, got %v", res.Header())
+	}
+	// rate limit
+	if res = serve(testURI, "10.0.0.1:1234"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", res.Code)
+	}
+	// not found
+	if res = serve("/unknown", "10.0.0.2:1234"); res.Code != http.StatusTeapot {
+		t.Fatalf("expected 418, got %d", res.Code)
+	}
+	// recover
+	if res = serve("/panic", "10.0.0.3:1234"); res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", res.Code)
+	}
+	// trusted proxies
+	if len(handler.proxies) != 1 {
+		t.Fatalf("expected 1 trusted proxy, got %d", len(handler.proxies))
+	}
+}
+
+func TestNewHandlerConfigOption(t *testing.T) {
+	// nil config and nil options are ignored
+	handler := NewHandler(nil, nil)
+	if handler.cors || handler.limiter != nil {
+		t.Fatalf("expected the default config")
+	}
+	// the config can be combined with the functional options, that are
+	// applied in order
+	handler = NewHandler(&Config{CORS: true}, WithCORS(false))
+	if handler.cors {
+		t.Fatalf("expected CORS to be disabled")
+	}
+	handler = NewHandler(WithCORS(false), &Config{CORS: true})
+	if !handler.cors {
+		t.Fatalf("expected CORS to be enabled")
+	}
+
+	store := &fakeStore{}
+	handler = NewHandler(WithRateLimit(1, 1), WithLimiterStore(store))
+	_ = handler.Get(testPath, testHandler)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	if len(store.keys) != 1 {
+		t.Fatalf("expected the store to be consulted, got %v", store.keys)
+	}
+}
@@ -0,0 +1,179 @@
+package apihandler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxyKey is the context key to store if the request was received
+// from a trusted proxy.
+const trustedProxyKey contextKey = "trusted_proxy"
+
+// clientIPKey is the context key to store the IP address of the client of a
+// request received from a trusted proxy, resolved from the forwarded headers.
+const clientIPKey contextKey = "client_ip"
+
+// defaultForwardedHeaders contains the headers used by default to resolve the
+// client of the requests received from trusted proxies, in order.
+var defaultForwardedHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+// parsePrefixes function parses the provided list of IP addresses and CIDR
+// ranges, like the trusted proxies. Invalid entries are ignored.
+func parsePrefixes(entries []string) []netip.Prefix {
+	prefixes := []netip.Prefix{}
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, ok := parseClientAddr(entry); ok {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		}
+	}
+	return prefixes
+}
+
+// parseClientAddr function parses the IP address of the provided remote
+// address of a request, with or without port, supporting IPv4 and IPv6
+// addresses, bracketed or not. The IPv4-mapped IPv6 addresses are unmapped and
+// the zone of the IPv6 addresses is removed. It returns false if the remote
+// address is not an IP address.
+func parseClientAddr(remoteAddr string) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(remoteAddr, "["), "]")
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// containsAddr function returns if the provided remote address of a request
+// belongs to any of the provided IP prefixes.
+func containsAddr(prefixes []netip.Prefix, remoteAddr string) bool {
+	if len(prefixes) == 0 {
+		return false
+	}
+	addr, ok := parseClientAddr(remoteAddr)
+	if !ok {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrustedProxy method returns if the provided remote address of a request
+// belongs to one of the trusted proxies of the Handler.
+func (m *Handler) isTrustedProxy(remoteAddr string) bool {
+	return containsAddr(m.proxies, remoteAddr)
+}
+
+// ExternalURL function returns the absolute URL of the provided path as it is
+// visible for the client of the provided request, using its scheme and host.
+// If the request was received by a Handler from one of the proxies defined in
+// Config.TrustedProxies, the 'X-Forwarded-Proto' and 'X-Forwarded-Host'
+// headers are used instead, if they are defined.
+func ExternalURL(r *http.Request, path string) string {
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if trusted, _ := r.Context().Value(trustedProxyKey).(bool); trusted {
+		if proto := forwardedValue(r.Header.Get("X-Forwarded-Proto")); proto != "" {
+			scheme = strings.ToLower(proto)
+		}
+		if forwardedHost := forwardedValue(r.Header.Get("X-Forwarded-Host")); forwardedHost != "" {
+			host = forwardedHost
+		}
+	}
+	if !strings.HasPrefix(path, uriSeparator) {
+		path = uriSeparator + path
+	}
+	return scheme + "://" + host + path
+}
+
+// forwardedValue function returns the first value of the provided forwarded
+// header, which contains the value set by the proxy closest to the client.
+func forwardedValue(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+	return strings.TrimSpace(value)
+}
+
+// withTrustedProxy function returns a copy of the provided context that marks
+// the request as received from a trusted proxy, whose client has the provided
+// IP address.
+func withTrustedProxy(ctx context.Context, clientIP string) context.Context {
+	ctx = context.WithValue(ctx, trustedProxyKey, true)
+	return context.WithValue(ctx, clientIPKey, clientIP)
+}
+
+// ClientIP function returns the IP address of the client of the provided
+// request. If the request was received by a Handler from one of the proxies
+// defined in Config.TrustedProxies, it is resolved from the forwarded headers
+// defined in Config.ForwardedHeaders. Otherwise, the forwarded headers are
+// ignored to prevent spoofing, and the host of the remote address of the
+// request is returned.
+func ClientIP(r *http.Request) string {
+	if clientIP, ok := r.Context().Value(clientIPKey).(string); ok {
+		return clientIP
+	}
+	return remoteHost(r.RemoteAddr)
+}
+
+// remoteHost function returns the host of the provided remote address,
+// without port and brackets if they are defined.
+func remoteHost(remoteAddr string) string {
+	if addr, ok := parseClientAddr(remoteAddr); ok {
+		return addr.String()
+	}
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// forwardedClient method returns the IP address of the client of the provided
+// request, received from a trusted proxy, using the first configured
+// forwarded header that contains a valid IP address. The 'X-Forwarded-For'
+// header is read from the right, where the trusted proxies append the
+// addresses: if the number of trusted hops is defined, that number of entries
+// is skipped, otherwise the entries of trusted proxies are skipped. If no
+// header contains a valid address, the host of the remote address is used.
+func (m *Handler) forwardedClient(req *http.Request) string {
+	for _, header := range m.fwdHeaders {
+		if !strings.EqualFold(header, "X-Forwarded-For") {
+			if addr, ok := parseClientAddr(strings.TrimSpace(req.Header.Get(header))); ok {
+				return addr.String()
+			}
+			continue
+		}
+		entries := strings.Split(strings.Join(req.Header.Values(header), ","), ",")
+		if m.proxyHops > 0 {
+			if idx := len(entries) - m.proxyHops; idx >= 0 {
+				if addr, ok := parseClientAddr(strings.TrimSpace(entries[idx])); ok {
+					return addr.String()
+				}
+			}
+			continue
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := strings.TrimSpace(entries[i])
+			addr, ok := parseClientAddr(entry)
+			if !ok {
+				break
+			}
+			if i > 0 && m.isTrustedProxy(entry) {
+				continue
+			}
+			return addr.String()
+		}
+	}
+	return remoteHost(req.RemoteAddr)
+}
@@ -0,0 +1,179 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExternalURL(t *testing.T) {
+	handler := NewHandler(&Config{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1", "invalid"}})
+	var url string
+	_ = handler.Get("/callback", func(w http.ResponseWriter, r *http.Request) {
+		url = ExternalURL(r, "next?page=2")
+	})
+	serve := func(remoteAddr string) string {
+		req := httptest.NewRequest(http.MethodGet, "http://internal:8080/callback", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-Proto", "HTTPS")
+		req.Header.Set("X-Forwarded-Host", "api.example.com, internal:8080")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return url
+	}
+
+	// direct requests ignore the forwarded headers
+	if value := serve("203.0.113.1:1234"); value != "http://internal:8080/next?page=2" {
+		t.Fatalf("expected 'http://internal:8080/next?page=2', got '%s'", value)
+	}
+	// proxied requests use them
+	for _, addr := range []string{"10.1.2.3:1234", "192.168.1.1:1234"} {
+		if value := serve(addr); value != "https://api.example.com/next?page=2" {
+			t.Fatalf("expected 'https://api.example.com/next?page=2', got '%s'", value)
+		}
+	}
+	if value := serve("192.168.1.2:1234"); value != "http://internal:8080/next?page=2" {
+		t.Fatalf("expected 'http://internal:8080/next?page=2', got '%s'", value)
+	}
+}
+
+func TestParseClientAddr(t *testing.T) {
+	tests := []struct {
+		remoteAddr, expected string
+		ok                   bool
+	}{
+		{"10.0.0.1:1234", "10.0.0.1", true},
+		{"10.0.0.1", "10.0.0.1", true},
+		{"[2001:db8::1]:443", "2001:db8::1", true},
+		{"[2001:db8::1]", "2001:db8::1", true},
+		{"2001:db8::1", "2001:db8::1", true},
+		{"[::1]:8080", "::1", true},
+		{"::1", "::1", true},
+		{"[fe80::1%eth0]:443", "fe80::1", true},
+		{"fe80::1%eth0", "fe80::1", true},
+		{"[::ffff:10.0.0.1]:1234", "10.0.0.1", true},
+		{"example.com:80", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		addr, ok := parseClientAddr(test.remoteAddr)
+		if ok != test.ok {
+			t.Fatalf("expected %t for '%s', got %t", test.ok, test.remoteAddr, ok)
+		}
+		if ok && addr.String() != test.expected {
+			t.Fatalf("expected '%s' for '%s', got '%s'", test.expected, test.remoteAddr, addr)
+		}
+	}
+}
+
+func TestContainsAddrIPv6(t *testing.T) {
+	prefixes := parsePrefixes([]string{"::1", "fe80::/10", "[2001:db8::1]", "10.0.0.0/8"})
+	tests := []struct {
+		remoteAddr string
+		expected   bool
+	}{
+		{"[::1]:1234", true},
+		{"::1", true},
+		{"[fe80::abcd%eth0]:1234", true},
+		{"[2001:db8::1]:443", true},
+		{"2001:db8::2", false},
+		{"[::ffff:10.1.2.3]:1234", true},
+		{"192.168.0.1:1234", false},
+	}
+	for _, test := range tests {
+		if contains := containsAddr(prefixes, test.remoteAddr); contains != test.expected {
+			t.Fatalf("expected %t for '%s', got %t", test.expected, test.remoteAddr, contains)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	var clientIP string
+	serve := func(handler *Handler, remoteAddr string, headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return clientIP
+	}
+	ipHandler := func(w http.ResponseWriter, r *http.Request) {
+		clientIP = ClientIP(r)
+	}
+
+	// without trusted proxies the forwarded headers are ignored
+	handler := NewHandler(nil)
+	_ = handler.Get("/ip", ipHandler)
+	spoofed := map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "5.6.7.8"}
+	if ip := serve(handler, "203.0.113.7:1234", spoofed); ip != "203.0.113.7" {
+		t.Fatalf("expected '203.0.113.7', got '%s'", ip)
+	}
+
+	handler = NewHandler(&Config{TrustedProxies: []string{"10.0.0.0/8"}})
+	_ = handler.Get("/ip", ipHandler)
+	// requests from untrusted addresses are not resolved
+	if ip := serve(handler, "203.0.113.7:1234", spoofed); ip != "203.0.113.7" {
+		t.Fatalf("expected '203.0.113.7', got '%s'", ip)
+	}
+	// single trusted proxy, the spoofed entry of the client is skipped
+	headers := map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1"}
+	if ip := serve(handler, "10.0.0.1:1234", headers); ip != "198.51.100.1" {
+		t.Fatalf("expected '198.51.100.1', got '%s'", ip)
+	}
+	// chain of trusted proxies
+	headers = map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.2"}
+	if ip := serve(handler, "10.0.0.1:1234", headers); ip != "198.51.100.1" {
+		t.Fatalf("expected '198.51.100.1', got '%s'", ip)
+	}
+	// X-Real-IP fallback
+	headers = map[string]string{"X-Real-IP": "198.51.100.2"}
+	if ip := serve(handler, "10.0.0.1:1234", headers); ip != "198.51.100.2" {
+		t.Fatalf("expected '198.51.100.2', got '%s'", ip)
+	}
+	// no forwarded headers
+	if ip := serve(handler, "10.0.0.1:1234", nil); ip != "10.0.0.1" {
+		t.Fatalf("expected '10.0.0.1', got '%s'", ip)
+	}
+
+	// configured hops and headers
+	handler = NewHandler(&Config{
+		TrustedProxies:   []string{"10.0.0.1"},
+		TrustedProxyHops: 2,
+		ForwardedHeaders: []string{"X-Real-IP", "X-Forwarded-For"},
+	})
+	_ = handler.Get("/ip", ipHandler)
+	headers = map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 172.16.0.1"}
+	if ip := serve(handler, "10.0.0.1:1234", headers); ip != "198.51.100.1" {
+		t.Fatalf("expected '198.51.100.1', got '%s'", ip)
+	}
+	headers["X-Real-IP"] = "198.51.100.3"
+	if ip := serve(handler, "10.0.0.1:1234", headers); ip != "198.51.100.3" {
+		t.Fatalf("expected '198.51.100.3', got '%s'", ip)
+	}
+}
+
+func TestClientIPRateLimit(t *testing.T) {
+	handler := NewHandler(&Config{
+		TrustedProxies:  []string{"10.0.0.1"},
+		RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 1},
+	})
+	_ = handler.Get(testPath, testHandler)
+	serve := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+	// the clients behind the trusted proxy are limited separately
+	if code := serve("198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve("198.51.100.2"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+}
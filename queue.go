@@ -0,0 +1,50 @@
+package apihandler
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// QueueLimit function returns a Middleware that limits the number of requests
+// served concurrently to maxConcurrent. Requests beyond that limit wait in a
+// queue of maxQueue requests for up to the provided wait duration. Requests
+// that cannot be queued because the queue is full, or that cannot be served
+// within the wait duration, are rejected with a 503 HTTP error.
+func QueueLimit(maxConcurrent, maxQueue int, wait time.Duration) Middleware {
+	sem := make(chan struct{}, maxConcurrent)
+	var queued int64
+	reject := func(w http.ResponseWriter) {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+			default:
+				// try to queue the request if there is room in the queue
+				if atomic.AddInt64(&queued, 1) > int64(maxQueue) {
+					atomic.AddInt64(&queued, -1)
+					reject(w)
+					return
+				}
+				timer := time.NewTimer(wait)
+				select {
+				case sem <- struct{}{}:
+					timer.Stop()
+					atomic.AddInt64(&queued, -1)
+				case <-timer.C:
+					atomic.AddInt64(&queued, -1)
+					reject(w)
+					return
+				case <-r.Context().Done():
+					timer.Stop()
+					atomic.AddInt64(&queued, -1)
+					return
+				}
+			}
+			defer func() { <-sem }()
+			next(w, r)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueueLimit(t *testing.T) {
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	handler := QueueLimit(1, 1, time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func() *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+		return res
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*httptest.ResponseRecorder, 2)
+	// the first request is served and the second one is queued
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0] = serve()
+	}()
+	<-started
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1] = serve()
+	}()
+	time.Sleep(50 * time.Millisecond)
+	// the queue is full, so the third request is rejected
+	if res := serve(); res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", res.Code)
+	}
+	close(release)
+	wg.Wait()
+	for _, res := range results {
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", res.Code)
+		}
+	}
+	if len(started) != 1 {
+		t.Fatalf("expected the queued request to be served, got %d", len(started))
+	}
+
+	// queued requests that exceed the wait duration are rejected
+	block := make(chan struct{})
+	handler = QueueLimit(1, 1, 20*time.Millisecond)(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	})
+	go serve()
+	time.Sleep(20 * time.Millisecond)
+	if res := serve(); res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", res.Code)
+	}
+	close(block)
+}
@@ -1,33 +1,286 @@
 package apihandler
 
 import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/time/rate"
 )
 
+// LimiterStore interface defines a storage of rate limiters, one per client
+// key, that decides if a request is allowed given the rate (requests per
+// second) and the burst size provided. It returns if the request is allowed
+// and the time when the client will be allowed to perform a new request, or
+// an error if the decision could not be made, for example, because a remote
+// storage is unreachable. It allows to replace the default in-memory storage,
+// for example, with a shared one for distributed deployments.
+type LimiterStore interface {
+	Allow(key string, rate float64, burst int) (bool, time.Time, error)
+}
+
+// limiterResetter interface defines the LimiterStore that supports removing
+// the rate limiter of a client key, used by `Handler.ResetRateLimit`.
+type limiterResetter interface {
+	Reset(key string)
+}
+
+// limiterQuota interface defines the LimiterStore that also reports the number
+// of requests that the client can still perform, used to set the
+// 'X-RateLimit-Remaining' header. AllowQuota works as LimiterStore.Allow but
+// also returns the remaining requests after the current one.
+type limiterQuota interface {
+	AllowQuota(key string, rate float64, burst int) (bool, int, time.Time, error)
+}
+
+// limiterRemainer interface defines the LimiterStore that supports querying
+// the requests that a client can still perform, used by
+// `Handler.RateLimitRemaining`. It returns a negative number if the client is
+// not tracked.
+type limiterRemainer interface {
+	Remaining(key string) int
+}
+
+// limiterLister interface defines the LimiterStore that supports listing the
+// client keys of its rate limiters, used by `Handler.RateLimitClients`.
+type limiterLister interface {
+	Clients() []string
+}
+
+// limiterClearer interface defines the LimiterStore that supports removing
+// the rate limiters of every client key, used by `Handler.ClearRateLimits`.
+type limiterClearer interface {
+	Clear()
+}
+
 // rateLimiter struct contains the list of IP addresses and their rate limiter
-// to control the number of requests (b) per frequency defined (r).
+// to control the number of requests (burst) per frequency defined (rate). It
+// is the default in-memory LimiterStore. If the ttl is defined, the rate
+// limiters that are not used during that time are removed.
 type rateLimiter struct {
-	ipList sync.Map
-	r      rate.Limit
-	b      int
+	ipList    sync.Map
+	ttl       time.Duration
+	sweepMtx  sync.Mutex
+	lastSweep time.Time
+}
+
+// limiterEntry struct contains the rate limiter of a client and the last time
+// that it was used, as Unix time in nanoseconds.
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen atomic.Int64
 }
 
 // Add method creates a new rate limiter for the provided IP address and stores
 // it in the list of rate limiters.
-func (al *rateLimiter) Add(ip string) *rate.Limiter {
-	limiter := rate.NewLimiter(al.r, al.b)
-	al.ipList.Store(ip, limiter)
-	return limiter
+func (al *rateLimiter) Add(ip string, r rate.Limit, b int) *rate.Limiter {
+	entry := &limiterEntry{limiter: rate.NewLimiter(r, b)}
+	entry.lastSeen.Store(time.Now().UnixNano())
+	al.ipList.Store(ip, entry)
+	return entry.limiter
 }
 
 // Get method returns the rate limiter for the provided IP address if it exists
 // in the list of rate limiters, otherwise creates a new rate limiter and stores
-// it in the list.
-func (al *rateLimiter) Get(ip string) *rate.Limiter {
-	if limiter, ok := al.ipList.Load(ip); ok {
-		return limiter.(*rate.Limiter)
+// it in the list. It also updates the last time that the limiter was used.
+func (al *rateLimiter) Get(ip string, r rate.Limit, b int) *rate.Limiter {
+	if value, ok := al.ipList.Load(ip); ok {
+		entry := value.(*limiterEntry)
+		entry.lastSeen.Store(time.Now().UnixNano())
+		return entry.limiter
+	}
+	return al.Add(ip, r, b)
+}
+
+// sweep method removes the rate limiters that were not used during the ttl
+// before the provided time. It only runs if the ttl is defined and at most
+// once per ttl, so it can be called on every request.
+func (al *rateLimiter) sweep(now time.Time) {
+	if al.ttl <= 0 {
+		return
+	}
+	al.sweepMtx.Lock()
+	if now.Sub(al.lastSweep) < al.ttl {
+		al.sweepMtx.Unlock()
+		return
+	}
+	al.lastSweep = now
+	al.sweepMtx.Unlock()
+	expiration := now.Add(-al.ttl).UnixNano()
+	al.ipList.Range(func(key, value any) bool {
+		if value.(*limiterEntry).lastSeen.Load() < expiration {
+			al.ipList.Delete(key)
+		}
+		return true
+	})
+}
+
+// Allow method implements the LimiterStore interface. It consumes a token of
+// the rate limiter of the provided key, creating it with the rate and burst
+// provided if it does not exist, and returns if the request is allowed and
+// the time when the rate limiter will have a token available again. It never
+// returns an error.
+func (al *rateLimiter) Allow(key string, r float64, b int) (bool, time.Time, error) {
+	allowed, _, reset, err := al.AllowQuota(key, r, b)
+	return allowed, reset, err
+}
+
+// AllowQuota method works as Allow but also returns the number of tokens that
+// remain available in the rate limiter of the provided key, which are the
+// requests that the client can still perform immediately.
+func (al *rateLimiter) AllowQuota(key string, r float64, b int) (bool, int, time.Time, error) {
+	now := time.Now()
+	al.sweep(now)
+	limiter := al.Get(key, rate.Limit(r), b)
+	allowed := limiter.AllowN(now, 1)
+	remaining := int(math.Max(0, math.Floor(limiter.TokensAt(now))))
+	return allowed, remaining, resetAt(limiter, now), nil
+}
+
+// Reset method removes the rate limiter of the provided key, so the next
+// request of the client starts with a new one.
+func (al *rateLimiter) Reset(key string) {
+	al.ipList.Delete(key)
+}
+
+// Remaining method returns the number of tokens available in the rate limiter
+// of the provided key, which are the requests that the client can still
+// perform immediately. It returns -1 if the client has no rate limiter.
+func (al *rateLimiter) Remaining(key string) int {
+	value, ok := al.ipList.Load(key)
+	if !ok {
+		return -1
+	}
+	tokens := value.(*limiterEntry).limiter.TokensAt(time.Now())
+	return int(math.Max(0, math.Floor(tokens)))
+}
+
+// Clients method returns the sorted list of the keys of the clients that have
+// a rate limiter.
+func (al *rateLimiter) Clients() []string {
+	clients := []string{}
+	al.ipList.Range(func(key, _ any) bool {
+		clients = append(clients, key.(string))
+		return true
+	})
+	sort.Strings(clients)
+	return clients
+}
+
+// Clear method removes the rate limiters of every client, so the next request
+// of every client starts with a new one.
+func (al *rateLimiter) Clear() {
+	al.ipList.Range(func(key, _ any) bool {
+		al.ipList.Delete(key)
+		return true
+	})
+}
+
+// resetAt function returns the time when the provided rate limiter will have
+// at least one token available again, calculated from the tokens available at
+// the provided time and its rate. If the rate is not positive, the provided
+// time is returned.
+func resetAt(limiter *rate.Limiter, now time.Time) time.Time {
+	tokens, r := limiter.TokensAt(now), limiter.Limit()
+	if tokens >= 1 || r <= 0 || r == rate.Inf {
+		return now
+	}
+	seconds := (1 - tokens) / float64(r)
+	return now.Add(time.Duration(math.Ceil(seconds * float64(time.Second))))
+}
+
+// allow method checks if the client of the provided key is allowed to perform
+// a request using the configured LimiterStore, setting the rate limit headers
+// of the response as allowClient does. If the LimiterStore fails, the error
+// is sent to the errors channel wrapping ErrLimiterStore, and the request is
+// allowed, unless Config.RateLimitFailClosed is set.
+func (m *Handler) allow(res http.ResponseWriter, key string) (bool, time.Time, error) {
+	allowed, reset, err := allowClient(res, m.limiter, key, m.rate, m.burst)
+	if err != nil {
+		err = fmt.Errorf("%w for '%s': %w", ErrLimiterStore, key, err)
+		m.report(err)
+		return !m.failClosed, reset, err
+	}
+	return allowed, reset, nil
+}
+
+// allowClient function checks if the client of the provided key is allowed to
+// perform a request using the provided LimiterStore, rate and burst, and sets
+// the 'X-RateLimit-Limit', 'X-RateLimit-Remaining' and 'X-RateLimit-Reset' (as
+// Unix time in seconds) headers of the response, and the 'Retry-After' header
+// if it is not allowed. The remaining requests are only reported when they are
+// known: if the LimiterStore implements 'AllowQuota' or the request is not
+// allowed. If the LimiterStore returns an error, no header is set and the
+// error is returned.
+func allowClient(res http.ResponseWriter, store LimiterStore, key string, r float64, burst int) (bool, time.Time, error) {
+	var allowed bool
+	var reset time.Time
+	var err error
+	remaining := -1
+	if quota, ok := store.(limiterQuota); ok {
+		allowed, remaining, reset, err = quota.AllowQuota(key, r, burst)
+	} else {
+		allowed, reset, err = store.Allow(key, r, burst)
+	}
+	if err != nil {
+		return false, reset, err
+	}
+	if !allowed {
+		remaining = 0
+	}
+	header := res.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(burst))
+	if remaining >= 0 {
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	}
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(reset.UnixNano())/float64(time.Second))), 10))
+	if !allowed {
+		retryAfter := int(math.Ceil(time.Until(reset).Seconds()))
+		header.Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	}
+	return allowed, reset, nil
+}
+
+// rateLimitKey function returns the key that identifies the client of the
+// provided request for rate limiting: the key returned by the provided
+// function, if it is defined and the key is available, or the client IP
+// returned by ClientIP, which is resolved from the forwarded headers if the
+// request was received from a trusted proxy. The port of the client address
+// is not included, so the clients do not get a new rate limiter by
+// reconnecting.
+func rateLimitKey(req *http.Request, key func(*http.Request) (string, bool)) string {
+	if key != nil {
+		if k, ok := key(req); ok {
+			return k
+		}
+	}
+	return ClientIP(req)
+}
+
+// RateLimit function returns a Middleware that limits the requests of every
+// client, identified by the key returned by the Key function of the provided
+// config or by its IP (see ClientIP), with the rate, burst and TTL of the
+// provided config, using the same in-memory token bucket store and response
+// headers than the rate limiter of the Handler. It allows to limit only some
+// routes or groups, or to apply different limits to them, responding with a
+// 429 HTTP error to the requests that exceed the limit. Since it runs inside
+// the Handler, the Key function can read the values stored in the request
+// context by the previous middlewares, like the authenticated user.
+func RateLimit(cfg RateLimitConfig) Middleware {
+	store := &rateLimiter{ttl: cfg.TTL}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// the in-memory store never fails
+			if allowed, _, _ := allowClient(w, store, rateLimitKey(r, cfg.Key), cfg.Rate, cfg.Limit); !allowed {
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next(w, r)
+		}
 	}
-	return al.Add(ip)
 }
@@ -0,0 +1,496 @@
+package apihandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeStore struct implements the LimiterStore interface recording the keys
+// that it receives and allowing only the requests of the allowed key, or
+// failing with the provided error if it is defined.
+type fakeStore struct {
+	allowed string
+	keys    []string
+	err     error
+}
+
+func (s *fakeStore) Allow(key string, rate float64, burst int) (bool, time.Time, error) {
+	s.keys = append(s.keys, key)
+	if s.err != nil {
+		return false, time.Time{}, s.err
+	}
+	return key == s.allowed, time.Now(), nil
+}
+
+func TestLimiterStore(t *testing.T) {
+	store := &fakeStore{allowed: "10.0.0.1"}
+	handler := NewHandler(&Config{
+		LimiterStore:    store,
+		RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1},
+	})
+	_ = handler.Get(testPath, testHandler)
+
+	req := httptest.NewRequest(http.MethodGet, testURI, nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, testURI, nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", res.Code)
+	}
+
+	if len(store.keys) != 2 || store.keys[0] != "10.0.0.1" || store.keys[1] != "10.0.0.2" {
+		t.Fatalf("expected the store to be consulted for both clients, got %v", store.keys)
+	}
+
+	// without rate limit config the store is not used
+	store.keys = nil
+	handler = NewHandler(&Config{LimiterStore: store})
+	_ = handler.Get(testPath, testHandler)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+	if len(store.keys) != 0 {
+		t.Fatalf("expected the store not to be consulted, got %v", store.keys)
+	}
+}
+
+func TestLimiterStoreError(t *testing.T) {
+	storeErr := errors.New("store unavailable")
+	for _, failClosed := range []bool{false, true} {
+		handler := NewHandler(&Config{
+			LimiterStore:        &fakeStore{err: storeErr},
+			RateLimitConfig:     &RateLimitConfig{Rate: 1, Limit: 1},
+			RateLimitFailClosed: failClosed,
+		})
+		_ = handler.Get(testPath, testHandler)
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+		expected := http.StatusOK
+		if failClosed {
+			expected = http.StatusServiceUnavailable
+		}
+		if res.Code != expected {
+			t.Fatalf("expected %d, got %d", expected, res.Code)
+		}
+		if value := res.Header().Get("X-RateLimit-Limit"); value != "" {
+			t.Fatalf("expected no rate limit headers, got '%s'", value)
+		}
+		select {
+		case err := <-handler.Errors():
+			if !errors.Is(err, ErrLimiterStore) || !errors.Is(err, storeErr) {
+				t.Fatalf("expected ErrLimiterStore wrapping the store error, got %s", err)
+			}
+		default:
+			t.Fatal("expected error in channel, got nothing")
+		}
+	}
+}
+
+func TestRateKeyFromContext(t *testing.T) {
+	type userKey struct{}
+	handler := NewHandler(&Config{
+		RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 1},
+		RateKeyFromContext: func(ctx context.Context) (string, bool) {
+			user, ok := ctx.Value(userKey{}).(string)
+			return user, ok
+		},
+	})
+	_ = handler.Get(testPath, testHandler)
+	// the authentication middleware wraps the whole handler
+	auth := func(w http.ResponseWriter, r *http.Request) {
+		if user := r.Header.Get("X-User"); user != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+		}
+		handler.ServeHTTP(w, r)
+	}
+	serve := func(user, addr string) int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = addr
+		req.Header.Set("X-User", user)
+		res := httptest.NewRecorder()
+		auth(res, req)
+		return res.Code
+	}
+
+	if code := serve("alice", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	// same user from other address is limited
+	if code := serve("alice", "10.0.0.2:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	// other user from the same address is allowed
+	if code := serve("bob", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	// anonymous requests fallback to the address
+	if code := serve("", "10.0.0.3:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve("", "10.0.0.3:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+}
+
+func TestResetRateLimit(t *testing.T) {
+	handler := NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 1}})
+	_ = handler.Get(testPath, testHandler)
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	handler.ResetRateLimit("10.0.0.1")
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	// stores without reset support and disabled rate limits are ignored
+	NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1}, LimiterStore: &fakeStore{}}).ResetRateLimit("key")
+	NewHandler(nil).ResetRateLimit("key")
+}
+
+func TestRateLimitClients(t *testing.T) {
+	handler := NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 1}})
+	_ = handler.Get(testPath, testHandler)
+	serve := func(addr string) int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = addr
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	for _, addr := range []string{"10.0.0.3:1234", "10.0.0.1:1234", "10.0.0.2:1234"} {
+		if code := serve(addr); code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+	clients := handler.RateLimitClients()
+	if len(clients) != 3 || clients[0] != "10.0.0.1" || clients[1] != "10.0.0.2" || clients[2] != "10.0.0.3" {
+		t.Fatalf("expected the three clients sorted, got %v", clients)
+	}
+	if code := serve("10.0.0.1:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	// the same client reconnecting from another port shares its limiter
+	if code := serve("10.0.0.1:5678"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	if clients := handler.RateLimitClients(); len(clients) != 3 {
+		t.Fatalf("expected three clients, got %v", clients)
+	}
+
+	handler.ClearRateLimits()
+	if clients := handler.RateLimitClients(); len(clients) != 0 {
+		t.Fatalf("expected no clients, got %v", clients)
+	}
+	if code := serve("10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	// stores without support and disabled rate limits are ignored
+	handler = NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1}, LimiterStore: &fakeStore{}})
+	handler.ClearRateLimits()
+	if clients := handler.RateLimitClients(); clients != nil {
+		t.Fatalf("expected nil, got %v", clients)
+	}
+	NewHandler(nil).ClearRateLimits()
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	handler := NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 0.5, Limit: 2}})
+	_ = handler.Get(testPath, testHandler)
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	start := time.Now().Unix()
+	for i, remaining := range []string{"1", "0"} {
+		res := serve()
+		if res.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", res.Code)
+		}
+		if value := res.Header().Get("X-RateLimit-Limit"); value != "2" {
+			t.Fatalf("expected limit '2', got '%s'", value)
+		}
+		if value := res.Header().Get("X-RateLimit-Remaining"); value != remaining {
+			t.Fatalf("expected remaining '%s' on request %d, got '%s'", remaining, i, value)
+		}
+		if res.Header().Get("Retry-After") != "" {
+			t.Fatalf("expected no 'Retry-After' header on allowed requests")
+		}
+	}
+
+	res := serve()
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", res.Code)
+	}
+	if value := res.Header().Get("X-RateLimit-Remaining"); value != "0" {
+		t.Fatalf("expected remaining '0', got '%s'", value)
+	}
+	// a token is available again in 2 seconds at most
+	reset, err := strconv.ParseInt(res.Header().Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < start || reset > start+3 {
+		t.Fatalf("expected reset within 3 seconds from %d, got %d (%v)", start, reset, err)
+	}
+	if value := res.Header().Get("Retry-After"); value != "1" && value != "2" {
+		t.Fatalf("expected 'Retry-After' of 1 or 2 seconds, got '%s'", value)
+	}
+
+	// stores that do not report the quota only report it when throttled
+	handler = NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1}, LimiterStore: &fakeStore{allowed: "10.0.0.1"}})
+	_ = handler.Get(testPath, testHandler)
+	if res = serve(); res.Header().Get("X-RateLimit-Limit") != "1" || res.Header().Get("X-RateLimit-Remaining") != "" {
+		t.Fatalf("expected limit without remaining, got %v", res.Header())
+	}
+}
+
+func TestRateLimiterTTL(t *testing.T) {
+	store := &rateLimiter{ttl: time.Minute}
+	store.Allow("idle", 1, 1)
+	store.Allow("active", 1, 1)
+	now := time.Now()
+	// the active client is used again before the ttl expires
+	store.sweep(now)
+	if value, ok := store.ipList.Load("active"); ok {
+		value.(*limiterEntry).lastSeen.Store(now.Add(30 * time.Second).UnixNano())
+	}
+	store.sweep(now.Add(time.Minute + time.Second))
+	if clients := store.Clients(); len(clients) != 1 || clients[0] != "active" {
+		t.Fatalf("expected only the active client, got %v", clients)
+	}
+
+	// without ttl the rate limiters are kept
+	store = &rateLimiter{}
+	store.Allow("idle", 1, 1)
+	store.sweep(now.Add(time.Hour))
+	if clients := store.Clients(); len(clients) != 1 {
+		t.Fatalf("expected the idle client, got %v", clients)
+	}
+
+	// the ttl is provided through the config
+	handler := NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1, TTL: time.Minute}})
+	if limiter, ok := handler.limiter.(*rateLimiter); !ok || limiter.ttl != time.Minute {
+		t.Fatalf("expected the in-memory store with the ttl")
+	}
+}
+
+func TestRateLimitExempt(t *testing.T) {
+	handler := NewHandler(
+		WithRateLimit(0.001, 1),
+		WithRateLimitExempt("10.0.0.1", "192.168.0.0/16", "invalid"),
+	)
+	_ = handler.Get(testPath, testHandler)
+	serve := func(addr string) int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = addr
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		for _, addr := range []string{"10.0.0.1:1234", "192.168.1.10:1234", "[::ffff:192.168.2.1]:1234"} {
+			if code := serve(addr); code != http.StatusOK {
+				t.Fatalf("expected 200 for exempted %s, got %d", addr, code)
+			}
+		}
+	}
+	if code := serve("10.0.0.2:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve("10.0.0.2:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	// the exempted clients do not consume tokens
+	if clients := handler.RateLimitClients(); len(clients) != 1 || clients[0] != "10.0.0.2" {
+		t.Fatalf("expected only the limited client, got %v", clients)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := NewHandler(nil)
+	limited := handler.Group("/limited")
+	limited.Use(RateLimit(RateLimitConfig{Rate: 0.001, Limit: 2}))
+	_ = limited.Get("/{name}", testHandler)
+	_ = handler.Get("/free/{name}", testHandler)
+	serve := func(path, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = addr
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		res := serve("/limited/a", "10.0.0.1:1234")
+		if res.Code != http.StatusOK || res.Header().Get("X-RateLimit-Remaining") != remaining {
+			t.Fatalf("expected 200 with remaining '%s', got %d %v", remaining, res.Code, res.Header())
+		}
+	}
+	res := serve("/limited/a", "10.0.0.1:1234")
+	if res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with 'Retry-After', got %d %v", res.Code, res.Header())
+	}
+	// the same client reconnecting from another port is still limited
+	if res = serve("/limited/a", "10.0.0.1:5678"); res.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", res.Code)
+	}
+	// other clients and routes are not limited
+	if res = serve("/limited/a", "10.0.0.2:1234"); res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if res = serve("/free/a", "10.0.0.1:1234"); res.Code != http.StatusOK || res.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("expected 200 without rate limit headers, got %d %v", res.Code, res.Header())
+		}
+	}
+}
+
+func TestRateLimitMiddlewareKey(t *testing.T) {
+	type userKey struct{}
+	handler := NewHandler(nil)
+	// the authentication middleware runs before the rate limiter
+	handler.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if user := r.Header.Get("X-User"); user != "" {
+				r = r.WithContext(context.WithValue(r.Context(), userKey{}, user))
+			}
+			next(w, r)
+		}
+	}, RateLimit(RateLimitConfig{Rate: 0.001, Limit: 1, Key: func(r *http.Request) (string, bool) {
+		user, ok := r.Context().Value(userKey{}).(string)
+		return user, ok
+	}}))
+	_ = handler.Get(testPath, testHandler)
+	serve := func(user, addr string) int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = addr
+		req.Header.Set("X-User", user)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	if code := serve("alice", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	// same user from other address is limited
+	if code := serve("alice", "10.0.0.2:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	// other user from the same address is allowed
+	if code := serve("bob", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	// anonymous requests fallback to the client IP
+	if code := serve("", "10.0.0.3:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve("", "10.0.0.3:5678"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+
+	// the rate limiter of the Handler also uses the key function
+	handler = NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 1, Key: func(r *http.Request) (string, bool) {
+		return r.Header.Get("X-User"), r.Header.Get("X-User") != ""
+	}}})
+	_ = handler.Get(testPath, testHandler)
+	if code := serve("alice", "10.0.0.1:1234"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := serve("alice", "10.0.0.2:1234"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	if keys := handler.RateLimitClients(); len(keys) != 1 || keys[0] != "alice" {
+		t.Fatalf("expected 'alice', got %v", keys)
+	}
+}
+
+func TestRateLimiterNoGoroutines(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		handler := NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1, TTL: time.Millisecond}})
+		_ = handler.Get(testPath, testHandler)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, testURI, nil))
+		_ = RateLimit(RateLimitConfig{Rate: 1, Limit: 1, TTL: time.Millisecond})
+	}
+	if current := runtime.NumGoroutine(); current > baseline {
+		t.Fatalf("expected no goroutines started by the rate limiters, got %d more", current-baseline)
+	}
+}
+
+func TestRateLimitRemaining(t *testing.T) {
+	handler := NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 0.001, Limit: 3}})
+	_ = handler.Get(testPath, testHandler)
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, testURI, nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	key := "10.0.0.1"
+	if remaining := handler.RateLimitRemaining(key); remaining != 3 {
+		t.Fatalf("expected 3 for an unknown client, got %d", remaining)
+	}
+	for _, expected := range []int{2, 1, 0} {
+		if code := serve(); code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+		if remaining := handler.RateLimitRemaining(key); remaining != expected {
+			t.Fatalf("expected %d, got %d", expected, remaining)
+		}
+	}
+	if code := serve(); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	if remaining := handler.RateLimitRemaining(key); remaining != 0 {
+		t.Fatalf("expected 0, got %d", remaining)
+	}
+	// reset restores the full allowance
+	handler.ResetRateLimit(key)
+	if remaining := handler.RateLimitRemaining(key); remaining != 3 {
+		t.Fatalf("expected 3 after reset, got %d", remaining)
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	// stores without support and disabled rate limits
+	handler = NewHandler(&Config{RateLimitConfig: &RateLimitConfig{Rate: 1, Limit: 1}, LimiterStore: &fakeStore{}})
+	if remaining := handler.RateLimitRemaining(key); remaining != -1 {
+		t.Fatalf("expected -1, got %d", remaining)
+	}
+	if remaining := NewHandler(nil).RateLimitRemaining(key); remaining != -1 {
+		t.Fatalf("expected -1, got %d", remaining)
+	}
+}
@@ -0,0 +1,233 @@
+package apihandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestDeadlineHeader constant contains the name of the header returned by
+// RemainingDeadlineHeader to propagate the request deadline.
+const RequestDeadlineHeader = "X-Request-Deadline"
+
+// MaxJSONBodySize constant defines the maximum size in bytes of the request
+// bodies decoded by DecodeJSON.
+const MaxJSONBodySize = 1 << 20
+
+// URIParam function returns the value of the named argument provided, decoded
+// from the request URI by the Handler, from the provided request context. It
+// returns an empty string if the argument does not exist.
+func URIParam(ctx context.Context, key string) string {
+	route, _ := ctx.Value(routeKey).(RouteContext)
+	return route.Params[key]
+}
+
+// LookupURIParam function returns the value of the named argument provided,
+// decoded from the request URI by the Handler, from the provided request
+// context. It returns false if the argument does not exist.
+func LookupURIParam(ctx context.Context, key string) (string, bool) {
+	route, _ := ctx.Value(routeKey).(RouteContext)
+	value, ok := route.Params[key]
+	return value, ok
+}
+
+// URIParamInt function returns the value of the named argument provided,
+// parsed as an integer. It returns an error wrapping ErrURIParamNotFound if
+// the argument does not exist, or the parsing error if it is not an integer.
+func URIParamInt(ctx context.Context, key string) (int, error) {
+	value, err := uriParam(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer uri param '%s': %w", key, err)
+	}
+	return n, nil
+}
+
+// URIParamFloat function returns the value of the named argument provided,
+// parsed as a float. It returns an error wrapping ErrURIParamNotFound if the
+// argument does not exist, or the parsing error if it is not a float.
+func URIParamFloat(ctx context.Context, key string) (float64, error) {
+	value, err := uriParam(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid float uri param '%s': %w", key, err)
+	}
+	return f, nil
+}
+
+// URIParamBool function returns the value of the named argument provided,
+// parsed as a boolean (accepting the values supported by strconv.ParseBool).
+// It returns an error wrapping ErrURIParamNotFound if the argument does not
+// exist, or the parsing error if it is not a boolean.
+func URIParamBool(ctx context.Context, key string) (bool, error) {
+	value, err := uriParam(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean uri param '%s': %w", key, err)
+	}
+	return b, nil
+}
+
+// uriParam function returns the value of the named argument provided or an
+// error wrapping ErrURIParamNotFound if it does not exist.
+func uriParam(ctx context.Context, key string) (string, error) {
+	value, ok := LookupURIParam(ctx, key)
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrURIParamNotFound, key)
+	}
+	return value, nil
+}
+
+// QueryString function returns the value of the provided query param of the
+// request, or the provided default value if the param is not present. If the
+// param is present but empty, the empty value is returned.
+func QueryString(r *http.Request, key, def string) string {
+	query := r.URL.Query()
+	if !query.Has(key) {
+		return def
+	}
+	return query.Get(key)
+}
+
+// QueryInt function returns the value of the provided query param of the
+// request parsed as an integer, or the provided default value if the param is
+// not present or it is not an integer. Use QueryIntStrict to tell both cases
+// apart.
+func QueryInt(r *http.Request, key string, def int) int {
+	n, err := QueryIntStrict(r, key)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// QueryIntStrict function returns the value of the provided query param of the
+// request parsed as an integer. It returns an error wrapping
+// ErrQueryParamNotFound if the param is not present, or the parsing error if
+// it is not an integer.
+func QueryIntStrict(r *http.Request, key string) (int, error) {
+	query := r.URL.Query()
+	if !query.Has(key) {
+		return 0, fmt.Errorf("%w: '%s'", ErrQueryParamNotFound, key)
+	}
+	n, err := strconv.Atoi(query.Get(key))
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer query param '%s': %w", key, err)
+	}
+	return n, nil
+}
+
+// QueryBool function returns the value of the provided query param of the
+// request parsed as a boolean (accepting the values supported by
+// strconv.ParseBool), or the provided default value if the param is not
+// present or it is not a boolean.
+func QueryBool(r *http.Request, key string, def bool) bool {
+	b, err := strconv.ParseBool(r.URL.Query().Get(key))
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// RemainingDeadlineHeader function returns the name and the value of the
+// header to propagate the deadline of the provided request context to the
+// downstream requests, formatted as a RFC 3339 timestamp in UTC. It returns
+// false if the context has no deadline.
+func RemainingDeadlineHeader(ctx context.Context) (name, value string, ok bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return "", "", false
+	}
+	return RequestDeadlineHeader, deadline.UTC().Format(time.RFC3339Nano), true
+}
+
+// ClientCertCN function returns the subject common name of the verified client
+// certificate of the provided request. It returns false if the request was not
+// received over TLS or the client did not provide a verified certificate.
+func ClientCertCN(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return "", false
+	}
+	chain := r.TLS.VerifiedChains[0]
+	if len(chain) == 0 {
+		return "", false
+	}
+	return chain[0].Subject.CommonName, true
+}
+
+// ParseAuthorization function splits the Authorization header of the provided
+// request into its scheme and credentials, trimming the surrounding
+// whitespaces. Since the schemes are case-insensitive, the scheme is returned
+// in lower case (e.g. "bearer" or "basic"). It returns false if the header is
+// missing or malformed.
+func ParseAuthorization(r *http.Request) (scheme, credentials string, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	i := strings.IndexAny(header, " \t")
+	if i < 0 {
+		return "", "", false
+	}
+	scheme, credentials = header[:i], strings.TrimSpace(header[i+1:])
+	if scheme == "" || credentials == "" {
+		return "", "", false
+	}
+	return strings.ToLower(scheme), credentials, true
+}
+
+// OnClientGone function calls the provided function in a new goroutine when
+// the provided request context is done, which happens when the client closes
+// the connection, allowing long running handlers to stop their work. Since
+// the request context is also cancelled when the request is completed, the
+// returned stop function must be called (usually deferred) when the handler
+// returns to release the watcher without calling the provided function. The
+// stop function returns false if the provided function was already called.
+// It is a shorthand of context.AfterFunc.
+func OnClientGone(ctx context.Context, fn func()) (stop func() bool) {
+	return context.AfterFunc(ctx, fn)
+}
+
+// DecodeJSON function decodes the JSON body of the provided request into the
+// provided value. It returns an error wrapping ErrUnsupportedContentType if
+// the request 'Content-Type' is not 'application/json' (its params, like
+// charset, are ignored), an error wrapping ErrBodyTooLarge if the body exceeds
+// MaxJSONBodySize bytes, or a decoding error if the body is not a single valid
+// JSON value for the provided one.
+func DecodeJSON(r *http.Request, v any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return fmt.Errorf("%w: '%s'", ErrUnsupportedContentType, r.Header.Get("Content-Type"))
+	}
+	if r.ContentLength > MaxJSONBodySize {
+		return fmt.Errorf("%w: %d bytes, max %d", ErrBodyTooLarge, r.ContentLength, MaxJSONBodySize)
+	}
+	body := &io.LimitedReader{R: r.Body, N: MaxJSONBodySize + 1}
+	decoder := json.NewDecoder(body)
+	if err := decoder.Decode(v); err != nil {
+		if body.N <= 0 {
+			return fmt.Errorf("%w: max %d bytes", ErrBodyTooLarge, MaxJSONBodySize)
+		}
+		return fmt.Errorf("error decoding JSON body: %w", err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		if body.N <= 0 {
+			return fmt.Errorf("%w: max %d bytes", ErrBodyTooLarge, MaxJSONBodySize)
+		}
+		return fmt.Errorf("error decoding JSON body: unexpected data after the JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package apihandler
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert function generates a certificate for the provided common name
+// signed by the parent certificate and key provided. If no parent is provided,
+// the certificate is self-signed and can be used as certificate authority.
+func testCert(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	return cert, key
+}
+
+func TestClientCertCN(t *testing.T) {
+	if _, ok := ClientCertCN(httptest.NewRequest(http.MethodGet, testURI, nil)); ok {
+		t.Fatal("expected false, got true")
+	}
+
+	ca, caKey := testCert(t, "test-ca", nil, nil)
+	clientCert, clientKey := testCert(t, "test-client", ca, caKey)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	handler := NewHandler(nil)
+	_ = handler.Get("/cn", func(w http.ResponseWriter, r *http.Request) {
+		cn, ok := ClientCertCN(r)
+		if !ok {
+			http.Error(w, "no client cert", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(cn))
+	})
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := srv.Client()
+	transport := client.Transport.(*http.Transport)
+	transport.TLSClientConfig.Certificates = []tls.Certificate{{
+		Certificate: [][]byte{clientCert.Raw},
+		PrivateKey:  clientKey,
+	}}
+	resp, err := client.Get(srv.URL + "/cn")
+	if err != nil {
+		t.Fatalf("expected nil, got error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("expected nil, got error: %s", err)
+	}
+	if string(body) != "test-client" {
+		t.Fatalf("expected 'test-client', got '%s'", string(body))
+	}
+}
+
+func TestURIParam(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get(testPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "name") + "|" + URIParam(r.Context(), "unknown")))
+	})
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if body := res.Body.String(); body != "args|" {
+		t.Fatalf("expected 'args|', got '%s'", body)
+	}
+}
+
+func TestTypedURIParams(t *testing.T) {
+	ctx := context.WithValue(context.Background(), routeKey, RouteContext{
+		Params: map[string]string{"id": "42", "ratio": "0.5", "active": "true", "name": "args"},
+	})
+	if value, ok := LookupURIParam(ctx, "name"); !ok || value != "args" {
+		t.Fatalf("expected 'args', got '%s'", value)
+	}
+	if _, ok := LookupURIParam(ctx, "unknown"); ok {
+		t.Fatal("expected false, got true")
+	}
+	if _, ok := LookupURIParam(context.Background(), "name"); ok {
+		t.Fatal("expected false, got true")
+	}
+
+	if n, err := URIParamInt(ctx, "id"); err != nil || n != 42 {
+		t.Fatalf("expected 42, got %d (%v)", n, err)
+	}
+	if f, err := URIParamFloat(ctx, "ratio"); err != nil || f != 0.5 {
+		t.Fatalf("expected 0.5, got %f (%v)", f, err)
+	}
+	if b, err := URIParamBool(ctx, "active"); err != nil || !b {
+		t.Fatalf("expected true, got %t (%v)", b, err)
+	}
+
+	if _, err := URIParamInt(ctx, "name"); err == nil || errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected parsing error, got %v", err)
+	}
+	if _, err := URIParamFloat(ctx, "name"); err == nil || errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected parsing error, got %v", err)
+	}
+	if _, err := URIParamBool(ctx, "name"); err == nil || errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected parsing error, got %v", err)
+	}
+
+	if _, err := URIParamInt(ctx, "unknown"); !errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected ErrURIParamNotFound, got %v", err)
+	}
+	if _, err := URIParamFloat(context.Background(), "ratio"); !errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected ErrURIParamNotFound, got %v", err)
+	}
+	if _, err := URIParamBool(ctx, "unknown"); !errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected ErrURIParamNotFound, got %v", err)
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?page=3&limit=ten&active=true&debug=nope&q=&name=alice", nil)
+
+	if value := QueryString(req, "name", "bob"); value != "alice" {
+		t.Fatalf("expected 'alice', got '%s'", value)
+	}
+	if value := QueryString(req, "q", "default"); value != "" {
+		t.Fatalf("expected empty value, got '%s'", value)
+	}
+	if value := QueryString(req, "missing", "default"); value != "default" {
+		t.Fatalf("expected 'default', got '%s'", value)
+	}
+
+	if n := QueryInt(req, "page", 1); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+	if n := QueryInt(req, "limit", 10); n != 10 {
+		t.Fatalf("expected 10, got %d", n)
+	}
+	if n := QueryInt(req, "missing", 1); n != 1 {
+		t.Fatalf("expected 1, got %d", n)
+	}
+
+	if b := QueryBool(req, "active", false); !b {
+		t.Fatal("expected true, got false")
+	}
+	if b := QueryBool(req, "debug", true); !b {
+		t.Fatal("expected true, got false")
+	}
+	if b := QueryBool(req, "missing", false); b {
+		t.Fatal("expected false, got true")
+	}
+
+	if n, err := QueryIntStrict(req, "page"); err != nil || n != 3 {
+		t.Fatalf("expected 3, got %d (%v)", n, err)
+	}
+	if _, err := QueryIntStrict(req, "limit"); err == nil || errors.Is(err, ErrQueryParamNotFound) {
+		t.Fatalf("expected parsing error, got %v", err)
+	}
+	if _, err := QueryIntStrict(req, "missing"); !errors.Is(err, ErrQueryParamNotFound) {
+		t.Fatalf("expected ErrQueryParamNotFound, got %v", err)
+	}
+}
+
+func TestRemainingDeadlineHeader(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	name, value, ok := RemainingDeadlineHeader(ctx)
+	if !ok || name != RequestDeadlineHeader {
+		t.Fatalf("expected '%s' header, got '%s' (%t)", RequestDeadlineHeader, name, ok)
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		t.Fatalf("expected nil, got error: %s", err)
+	}
+	if !parsed.Equal(deadline) {
+		t.Fatalf("expected %s, got %s", deadline, parsed)
+	}
+
+	if _, _, ok := RemainingDeadlineHeader(context.Background()); ok {
+		t.Fatal("expected false, got true")
+	}
+}
+
+func TestOnClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	gone := make(chan struct{})
+	stop := OnClientGone(ctx, func() { close(gone) })
+	cancel()
+	select {
+	case <-gone:
+	case <-time.After(time.Second):
+		t.Fatal("expected function to be called after cancellation")
+	}
+	if stop() {
+		t.Fatal("expected false, got true")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	called := make(chan struct{}, 1)
+	stop = OnClientGone(ctx, func() { called <- struct{}{} })
+	if !stop() {
+		t.Fatal("expected true, got false")
+	}
+	cancel()
+	select {
+	case <-called:
+		t.Fatal("expected function not to be called after stop")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestParseAuthorization(t *testing.T) {
+	tests := []struct {
+		header, scheme, credentials string
+		ok                          bool
+	}{
+		{"Bearer abc.def.ghi", "bearer", "abc.def.ghi", true},
+		{"  bEaReR \t abc.def.ghi  ", "bearer", "abc.def.ghi", true},
+		{"Basic dXNlcjpwYXNz", "basic", "dXNlcjpwYXNz", true},
+		{"Bearer", "", "", false},
+		{"Bearer   ", "", "", false},
+		{"", "", "", false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+		scheme, credentials, ok := ParseAuthorization(req)
+		if ok != test.ok {
+			t.Fatalf("expected %v for %q, got %v", test.ok, test.header, ok)
+		}
+		if scheme != test.scheme || credentials != test.credentials {
+			t.Fatalf("expected %q %q for %q, got %q %q", test.scheme, test.credentials, test.header, scheme, credentials)
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	request := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	var p payload
+	if err := DecodeJSON(request("application/json; charset=utf-8", `{"name":"alice"}`), &p); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if p.Name != "alice" {
+		t.Fatalf("expected 'alice', got '%s'", p.Name)
+	}
+
+	if err := DecodeJSON(request("text/plain", `{"name":"alice"}`), &p); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Fatalf("expected ErrUnsupportedContentType, got %v", err)
+	}
+	if err := DecodeJSON(request("application/json", `{"name":`), &p); err == nil || !strings.Contains(err.Error(), "error decoding JSON body") {
+		t.Fatalf("expected decoding error, got %v", err)
+	}
+	if err := DecodeJSON(request("application/json", `{"name":1}`), &p); err == nil {
+		t.Fatal("expected type error, got nil")
+	}
+	if err := DecodeJSON(request("application/json", `{"name":"a"} {"name":"b"}`), &p); err == nil {
+		t.Fatal("expected trailing data error, got nil")
+	}
+	large := `{"name":"` + strings.Repeat("a", MaxJSONBodySize) + `"}`
+	if err := DecodeJSON(request("application/json", large), &p); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("expected ErrBodyTooLarge, got %v", err)
+	}
+	req := request("application/json", large)
+	req.ContentLength = -1
+	if err := DecodeJSON(req, &p); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("expected ErrBodyTooLarge for unknown length, got %v", err)
+	}
+}
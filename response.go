@@ -0,0 +1,180 @@
+package apihandler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+)
+
+// responseWriter struct is the base of the http.ResponseWriter wrappers of
+// the package. It tracks if the headers were written to ignore duplicated
+// calls to WriteHeader, avoiding the superfluous WriteHeader warnings of
+// net/http, and if the connection was hijacked, to reject later writes. It
+// also delegates Flush, Hijack and Push to the wrapped writer if it supports
+// them.
+type responseWriter struct {
+	http.ResponseWriter
+	status   int
+	hijacked bool
+}
+
+// written method returns if the headers were already written or if the
+// connection was hijacked.
+func (w *responseWriter) written() bool {
+	return w.status != 0 || w.hijacked
+}
+
+// WriteHeader method writes the provided status code if the headers were not
+// written yet and the connection was not hijacked, otherwise it is ignored.
+func (w *responseWriter) WriteHeader(status int) {
+	if w.written() {
+		return
+	}
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write method writes the provided data, writing the headers with a 200 HTTP
+// status first if they were not written yet. It returns http.ErrHijacked if
+// the connection was hijacked.
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush method implements http.Flusher delegating to the wrapped writer if it
+// supports it.
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok && !w.hijacked {
+		if w.status == 0 {
+			w.WriteHeader(http.StatusOK)
+		}
+		flusher.Flush()
+	}
+}
+
+// Hijack method implements http.Hijacker delegating to the wrapped writer. It
+// returns http.ErrNotSupported if the wrapped writer does not support it.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Push method implements http.Pusher delegating to the wrapped writer. It
+// returns http.ErrNotSupported if the wrapped writer does not support it.
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap method returns the wrapped http.ResponseWriter, which allows to use
+// `http.ResponseController` with it.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ResponseRecorder struct wraps an http.ResponseWriter to record the status
+// code and the number of bytes of the body written by a handler, for example,
+// to build logging or metrics middlewares. Unlike httptest.ResponseRecorder,
+// the response is written to the wrapped writer, and Flush, Hijack and Push
+// are delegated to it if it supports them.
+type ResponseRecorder struct {
+	*responseWriter
+	bytes int64
+}
+
+// NewResponseRecorder function returns a ResponseRecorder that wraps the
+// provided http.ResponseWriter.
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{responseWriter: &responseWriter{ResponseWriter: w}}
+}
+
+// Write method writes the provided data to the wrapped writer, counting the
+// bytes written.
+func (w *ResponseRecorder) Write(data []byte) (int, error) {
+	n, err := w.responseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status method returns the status code written, which is 200 if the body was
+// written without calling WriteHeader or if nothing was written yet, as
+// net/http does.
+func (w *ResponseRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// BytesWritten method returns the number of bytes of the body written.
+func (w *ResponseRecorder) BytesWritten() int64 {
+	return w.bytes
+}
+
+// headResponseWriter struct wraps an http.ResponseWriter to discard the body
+// written by a GET handler when it is used to respond to a HEAD request, but
+// keeping the headers and the status code.
+type headResponseWriter struct {
+	*responseWriter
+}
+
+// Write method discards the provided data but reports it as written to keep
+// the handler working as usual.
+func (w *headResponseWriter) Write(data []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	return len(data), nil
+}
+
+// ServeAttachment function serves the provided content as an attachment with
+// the provided filename, setting the 'Content-Disposition' header. It
+// delegates to `http.ServeContent`, so the 'Content-Type' is detected from the
+// filename extension (if it is not already set), and range and conditional
+// requests are supported. If the provided modtime is not zero, it is used to
+// set the 'Last-Modified' header.
+func ServeAttachment(w http.ResponseWriter, r *http.Request, filename string, content io.ReadSeeker, modtime time.Time) {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	w.Header().Set("Content-Disposition", disposition)
+	http.ServeContent(w, r, filename, modtime, content)
+}
+
+// WriteJSON function writes the JSON encoding of the provided value as the
+// response with the provided status code, setting the 'Content-Type' header to
+// 'application/json'. The value is encoded before writing anything, so if it
+// can not be encoded, the error is returned and the response is not written.
+func WriteJSON(w http.ResponseWriter, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding JSON response: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("error writing JSON response: %w", err)
+	}
+	return nil
+}
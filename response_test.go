@@ -0,0 +1,180 @@
+package apihandler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeAttachment(t *testing.T) {
+	content := "id,name\n1,test\n"
+	modtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := NewHandler(nil)
+	_ = handler.Get("/report", func(w http.ResponseWriter, r *http.Request) {
+		ServeAttachment(w, r, "report.csv", strings.NewReader(content), modtime)
+	})
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if value := res.Header().Get("Content-Disposition"); value != `attachment; filename=report.csv` {
+		t.Fatalf("expected attachment disposition, got '%s'", value)
+	}
+	if value := res.Header().Get("Content-Type"); !strings.HasPrefix(value, "text/csv") {
+		t.Fatalf("expected 'text/csv', got '%s'", value)
+	}
+	if value := res.Header().Get("Last-Modified"); value != modtime.Format(http.TimeFormat) {
+		t.Fatalf("expected '%s', got '%s'", modtime.Format(http.TimeFormat), value)
+	}
+	if body := res.Body.String(); body != content {
+		t.Fatalf("expected '%s', got '%s'", content, body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/report", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusPartialContent {
+		t.Fatalf("expected 206, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "id" {
+		t.Fatalf("expected 'id', got '%s'", body)
+	}
+}
+
+func TestResponseWriterDoubleWriteHeader(t *testing.T) {
+	wrappers := map[string]Middleware{
+		"cookies": SecureCookies(CookieOptions{Secure: true}),
+		"gzip":    GzipMiddleware(gzip.DefaultCompression),
+	}
+	for name, mw := range wrappers {
+		logs := &bytes.Buffer{}
+		srv := httptest.NewUnstartedServer(mw(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("created"))
+		}))
+		srv.Config.ErrorLog = log.New(logs, "", 0)
+		srv.Start()
+
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("expected nil for %s, got error: %s", name, err)
+		}
+		resp.Body.Close()
+		srv.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected 201 for %s, got %d", name, resp.StatusCode)
+		}
+		if strings.Contains(logs.String(), "superfluous") {
+			t.Fatalf("expected no superfluous WriteHeader warning for %s, got '%s'", name, logs.String())
+		}
+	}
+}
+
+func TestResponseWriterHijack(t *testing.T) {
+	writeErr := make(chan error, 1)
+	handler := SecureCookies(CookieOptions{Secure: true})(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			writeErr <- err
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = rw.Flush()
+		_, err = w.Write([]byte("late"))
+		w.WriteHeader(http.StatusInternalServerError)
+		writeErr <- err
+	})
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected nil, got error: %s", err)
+	}
+	body, _ := io.ReadAll(bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	if string(body) != "hijacked" {
+		t.Fatalf("expected 'hijacked', got '%s'", string(body))
+	}
+	if err := <-writeErr; err != http.ErrHijacked {
+		t.Fatalf("expected http.ErrHijacked, got %v", err)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	res := httptest.NewRecorder()
+	if err := WriteJSON(res, http.StatusCreated, map[string]string{"status": "ok"}); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", res.Code)
+	}
+	if value := res.Header().Get("Content-Type"); value != "application/json" {
+		t.Fatalf("expected 'application/json', got '%s'", value)
+	}
+	if body := res.Body.String(); body != `{"status":"ok"}` {
+		t.Fatalf("expected '{\"status\":\"ok\"}', got '%s'", body)
+	}
+
+	// values that can not be encoded are not written
+	res = httptest.NewRecorder()
+	if err := WriteJSON(res, http.StatusOK, func() {}); err == nil {
+		t.Fatal("expected encoding error, got nil")
+	}
+	if res.Body.Len() != 0 || res.Header().Get("Content-Type") != "" {
+		t.Fatalf("expected nothing written, got '%s'", res.Body.String())
+	}
+}
+
+func TestResponseRecorder(t *testing.T) {
+	// implicit 200
+	res := httptest.NewRecorder()
+	rec := NewResponseRecorder(res)
+	if rec.Status() != http.StatusOK || rec.BytesWritten() != 0 {
+		t.Fatalf("expected 200 and 0 bytes, got %d and %d", rec.Status(), rec.BytesWritten())
+	}
+	_, _ = rec.Write([]byte("hello "))
+	_, _ = rec.Write([]byte("world"))
+	if rec.Status() != http.StatusOK || res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Status())
+	}
+	if rec.BytesWritten() != 11 || res.Body.String() != "hello world" {
+		t.Fatalf("expected 11 bytes written, got %d", rec.BytesWritten())
+	}
+
+	// explicit status
+	res = httptest.NewRecorder()
+	rec = NewResponseRecorder(res)
+	rec.WriteHeader(http.StatusNotFound)
+	rec.WriteHeader(http.StatusOK)
+	_, _ = rec.Write([]byte("not found"))
+	if rec.Status() != http.StatusNotFound || res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Status())
+	}
+	if rec.BytesWritten() != 9 {
+		t.Fatalf("expected 9 bytes, got %d", rec.BytesWritten())
+	}
+
+	// flush and hijack are delegated
+	rec.Flush()
+	if !res.Flushed {
+		t.Fatal("expected the wrapped writer to be flushed")
+	}
+	if _, _, err := rec.Hijack(); err != http.ErrNotSupported {
+		t.Fatalf("expected http.ErrNotSupported, got %v", err)
+	}
+}
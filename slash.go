@@ -0,0 +1,78 @@
+package apihandler
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TrailingSlashMode type defines how the Handler matches the request paths
+// that end with a slash.
+type TrailingSlashMode int
+
+const (
+	// TrailingSlashLenient mode ignores the trailing slash of the request
+	// paths when they are matched with the routes with named arguments, so
+	// '/users/123' and '/users/123/' match '/users/{id}'.
+	TrailingSlashLenient TrailingSlashMode = iota
+	// TrailingSlashStrict mode treats the request paths with a trailing slash
+	// as distinct paths, which only match the routes whose path also ends
+	// with a slash, and vice versa. The prefix and exact routes are not
+	// affected.
+	TrailingSlashStrict
+	// TrailingSlashRedirect mode redirects the requests whose path ends with
+	// a slash to the path without it, if it is registered for the request
+	// method, using Config.RedirectStatus or the default redirect status.
+	TrailingSlashRedirect
+)
+
+// matchSlash method returns if the trailing slash of the provided request URI
+// is allowed by the provided route according to the trailing slash mode of
+// the Handler. In strict mode, the request URI and the route path must both
+// end with a slash or not, except for the prefix routes.
+func (m *Handler) matchSlash(r *route, requestURI string) bool {
+	if m.slashMode != TrailingSlashStrict || r.prefix {
+		return true
+	}
+	return strings.HasSuffix(requestURI, uriSeparator) == strings.HasSuffix(r.path, uriSeparator)
+}
+
+// redirectStatus method returns the status code of the trailing slash
+// redirects for the provided request method: the configured one if it is
+// defined, 301 for the safe methods (GET and HEAD) or 308 for the rest, which
+// preserves their method and body.
+func (m *Handler) redirectStatus(method string) int {
+	if m.redirectCode != 0 {
+		return m.redirectCode
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return http.StatusMovedPermanently
+	}
+	return http.StatusPermanentRedirect
+}
+
+// redirectTrailingSlash method redirects the provided request to its path
+// without the trailing slash, preserving its query, if it ends with a slash
+// and there is a route registered for the request method and the canonical
+// path. The target is built from the escaped path, so the escaped slashes of
+// the request are kept, and the paths that would start with '//' are not
+// redirected, since the browsers resolve them as a different host. It
+// returns if the request was redirected.
+func (m *Handler) redirectTrailingSlash(res http.ResponseWriter, req *http.Request) bool {
+	path := req.URL.EscapedPath()
+	if len(path) <= 1 || !strings.HasSuffix(path, uriSeparator) {
+		return false
+	}
+	canonical := strings.TrimRight(path, uriSeparator)
+	if canonical == "" || strings.HasPrefix(canonical, "//") {
+		return false
+	}
+	if _, exist := m.find(req.Method, strings.TrimRight(matchPath(req.URL), uriSeparator)); !exist {
+		return false
+	}
+	target := canonical
+	if req.URL.RawQuery != "" {
+		target += "?" + req.URL.RawQuery
+	}
+	http.Redirect(res, req, target, m.redirectStatus(req.Method))
+	return true
+}
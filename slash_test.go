@@ -0,0 +1,111 @@
+package apihandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedirectTrailingSlash(t *testing.T) {
+	handler := NewHandler(&Config{RedirectTrailingSlash: true})
+	_ = handler.Get("/users", testHandler)
+	_ = handler.Post("/users", testHandler)
+
+	tests := []struct {
+		method   string
+		status   int
+		location string
+	}{
+		{http.MethodGet, http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodHead, http.StatusMovedPermanently, "/users?page=2"},
+		{http.MethodPost, http.StatusPermanentRedirect, "/users?page=2"},
+		{http.MethodPut, http.StatusMethodNotAllowed, ""},
+	}
+	for _, test := range tests {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(test.method, "/users/?page=2", nil))
+		if res.Code != test.status {
+			t.Fatalf("expected %d for %s, got %d", test.status, test.method, res.Code)
+		}
+		if location := res.Header().Get("Location"); location != test.location {
+			t.Fatalf("expected '%s' for %s, got '%s'", test.location, test.method, location)
+		}
+	}
+
+	// the canonical path is served as usual
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/users", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	// the escaped slashes are kept and the paths are never redirected to
+	// other hosts by catch-all routes
+	handler = NewHandler(&Config{RedirectTrailingSlash: true})
+	_ = handler.Get("/files/{name}", testHandler)
+	_ = handler.Get("/{path...}", testHandler)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/files/a%2Fb/", nil))
+	if location := res.Header().Get("Location"); res.Code != http.StatusMovedPermanently || location != "/files/a%2Fb" {
+		t.Fatalf("expected 301 to '/files/a%%2Fb', got %d '%s'", res.Code, location)
+	}
+	for _, path := range []string{"//evil.com/", "///evil.com/", "/%2Fevil.com/"} {
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		if location := res.Header().Get("Location"); strings.HasPrefix(location, "//") {
+			t.Fatalf("expected no redirect to other host for '%s', got '%s'", path, location)
+		}
+	}
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "//evil.com/", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	handler = NewHandler(&Config{RedirectTrailingSlash: true, RedirectStatus: http.StatusFound})
+	_ = handler.Post("/users", testHandler)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/users/", nil))
+	if res.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", res.Code)
+	}
+}
+
+func TestTrailingSlashModes(t *testing.T) {
+	tests := []struct {
+		mode           TrailingSlashMode
+		uri            string
+		status         int
+		location, body string
+	}{
+		{TrailingSlashLenient, "/users/123", http.StatusOK, "", "123"},
+		{TrailingSlashLenient, "/users/123/", http.StatusOK, "", "123"},
+		{TrailingSlashStrict, "/users/123", http.StatusOK, "", "123"},
+		{TrailingSlashStrict, "/users/123/", http.StatusNotFound, "", ""},
+		{TrailingSlashStrict, "/groups/", http.StatusOK, "", "groups"},
+		{TrailingSlashStrict, "/groups", http.StatusNotFound, "", ""},
+		{TrailingSlashRedirect, "/users/123", http.StatusOK, "", "123"},
+		{TrailingSlashRedirect, "/users/123/", http.StatusMovedPermanently, "/users/123", ""},
+	}
+	for _, test := range tests {
+		handler := NewHandler(&Config{TrailingSlash: test.mode, NotFound: http.NotFound})
+		_ = handler.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(URIParam(r.Context(), "id")))
+		})
+		_ = handler.Get("/groups/", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("groups"))
+		})
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, test.uri, nil))
+		if res.Code != test.status {
+			t.Fatalf("expected %d for '%s' in mode %d, got %d", test.status, test.uri, test.mode, res.Code)
+		}
+		if location := res.Header().Get("Location"); location != test.location {
+			t.Fatalf("expected '%s' for '%s' in mode %d, got '%s'", test.location, test.uri, test.mode, location)
+		}
+		if body := res.Body.String(); test.status == http.StatusOK && body != test.body {
+			t.Fatalf("expected '%s' for '%s' in mode %d, got '%s'", test.body, test.uri, test.mode, body)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package apihandler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// staticPathArg constant contains the name of the catch-all argument of the
+// routes registered by `Handler.StaticFS` that captures the file path.
+const staticPathArg = "filepath"
+
+// fileETags struct caches the 'ETag' headers of the files served by a route,
+// calculated from their content, so the files are only hashed again when
+// their modification time or size changes.
+type fileETags struct {
+	mtx   sync.Mutex
+	files map[string]fileETag
+}
+
+// fileETag struct contains the 'ETag' header of a version of a file,
+// identified by its modification time and size.
+type fileETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// get method returns the cached 'ETag' header of the file with the provided
+// name, if it was calculated for the same version of the file.
+func (c *fileETags) get(name string, info fs.FileInfo) (string, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	cached, ok := c.files[name]
+	if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+		return "", false
+	}
+	return cached.etag, true
+}
+
+// set method caches the provided 'ETag' header for the version of the file
+// with the provided name, replacing the previous one.
+func (c *fileETags) set(name string, info fs.FileInfo, etag string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.files == nil {
+		c.files = map[string]fileETag{}
+	}
+	c.files[name] = fileETag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+}
+
+// serveFile function serves the file with the provided name from the provided
+// filesystem. It sets a strong 'ETag' header calculated from the content of
+// the file, which is cached in the provided fileETags, so the files with the
+// same size and without modification time (like the embedded ones) get
+// different tags. It delegates to `http.ServeContent` with the file
+// modification time, so conditional requests ('If-None-Match' and
+// 'If-Modified-Since') are answered with a 304 HTTP status, and range
+// requests are supported. The files that do not support seeking are streamed
+// without range support once their tag is cached. It responds with a 404
+// HTTP error if the file does not exist or is a directory.
+func serveFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, etags *fileETags) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	content, seekable := file.(io.ReadSeeker)
+	etag, ok := etags.get(name, info)
+	if !ok {
+		// calculate the hash of the content, seeking back to the start if
+		// the file supports it, or reading it into memory otherwise
+		hash := sha256.New()
+		if seekable {
+			if _, err = io.Copy(hash, content); err == nil {
+				_, err = content.Seek(0, io.SeekStart)
+			}
+		} else {
+			var data []byte
+			if data, err = io.ReadAll(file); err == nil {
+				_, _ = hash.Write(data)
+				content, seekable = bytes.NewReader(data), true
+			}
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		etag = `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+		etags.set(name, info, etag)
+	}
+	w.Header().Set("ETag", etag)
+	if seekable {
+		http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+		return
+	}
+	// stream the files that can not seek, answering the conditional requests
+	// that match the 'ETag' header
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if ctype := mime.TypeByExtension(path.Ext(info.Name())); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	if !info.ModTime().IsZero() {
+		w.Header().Set("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodHead {
+		_, _ = io.Copy(w, file)
+	}
+}
+
+// matchETag function returns if any of the entity tags of the provided
+// 'If-None-Match' header value matches the provided one, using the weak
+// comparison (ignoring the 'W/' prefix).
+func matchETag(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// File method registers a GET route with the provided path that serves the
+// file with the provided name from the provided filesystem, including caching
+// headers ('ETag' and 'Last-Modified') and support for conditional and range
+// requests.
+func (m *Handler) File(path string, fsys fs.FS, name string, opts ...RouteOption) error {
+	etags := &fileETags{}
+	return m.Get(path, func(w http.ResponseWriter, r *http.Request) {
+		serveFile(w, r, fsys, name, etags)
+	}, opts...)
+}
+
+// Static method registers a GET route that serves the files of the provided
+// directory under the provided path prefix, which is stripped from the
+// request path to get the name of the file (e.g. '/assets/css/app.css' serves
+// './public/css/app.css' for the prefix '/assets/' and the directory
+// './public'). It wraps `Handler.StaticFS` using `os.DirFS`.
+func (m *Handler) Static(prefix, dir string, opts ...RouteOption) error {
+	return m.StaticFS(prefix, os.DirFS(dir), opts...)
+}
+
+// StaticFS method registers a GET route that serves the files of the provided
+// filesystem under the provided path prefix, using a catch-all argument to
+// resolve the nested paths. The files are served as `Handler.File` does, and
+// the requests for directories or missing files are answered with a 404 HTTP
+// error. The file paths that are not valid according to `fs.ValidPath`, like
+// the ones that contain '..' elements to traverse outside of the filesystem,
+// are rejected with a 400 HTTP error. As any other route, it is served
+// through the CORS, rate limit and middlewares of the Handler.
+func (m *Handler) StaticFS(prefix string, fsys fs.FS, opts ...RouteOption) error {
+	if !strings.HasSuffix(prefix, uriSeparator) {
+		prefix += uriSeparator
+	}
+	etags := &fileETags{}
+	return m.Get(prefix+"{"+staticPathArg+"...}", func(w http.ResponseWriter, r *http.Request) {
+		name := URIParam(r.Context(), staticPathArg)
+		if !fs.ValidPath(name) || strings.Contains(name, "\\") {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		serveFile(w, r, fsys, name, etags)
+	}, opts...)
+}
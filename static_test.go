@@ -0,0 +1,195 @@
+package apihandler
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	modtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<h1>index</h1>"), ModTime: modtime},
+	}
+	handler := NewHandler(nil)
+	if err := handler.File("/index", fsys, "index.html"); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	_ = handler.File("/missing", fsys, "missing.html")
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/index", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "<h1>index</h1>" {
+		t.Fatalf("expected '<h1>index</h1>', got '%s'", body)
+	}
+	etag := res.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("expected strong ETag header, got '%s'", etag)
+	}
+	if value := res.Header().Get("Last-Modified"); value != modtime.Format(http.TimeFormat) {
+		t.Fatalf("expected '%s', got '%s'", modtime.Format(http.TimeFormat), value)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/index", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/index", nil)
+	req.Header.Set("If-Modified-Since", modtime.Add(time.Hour).Format(http.TimeFormat))
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", res.Code)
+	}
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestFileETagContent(t *testing.T) {
+	// files without modification time and with the same size
+	fsys := fstest.MapFS{
+		"a.txt": &fstest.MapFile{Data: []byte("aaaa")},
+		"b.txt": &fstest.MapFile{Data: []byte("bbbb")},
+	}
+	handler := NewHandler(nil)
+	_ = handler.StaticFS("/files", fsys)
+	serve := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	etagA := serve("/files/a.txt", "").Header().Get("ETag")
+	etagB := serve("/files/b.txt", "").Header().Get("ETag")
+	if etagA == "" || etagA == etagB {
+		t.Fatalf("expected different ETags, got '%s' and '%s'", etagA, etagB)
+	}
+	if res := serve("/files/b.txt", etagA); res.Code != http.StatusOK || res.Body.String() != "bbbb" {
+		t.Fatalf("expected 200 with 'bbbb', got %d '%s'", res.Code, res.Body.String())
+	}
+	if res := serve("/files/a.txt", etagA); res.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", res.Code)
+	}
+
+	// a new version of the file gets a new tag
+	fsys["a.txt"] = &fstest.MapFile{Data: []byte("aaaaa")}
+	if res := serve("/files/a.txt", etagA); res.Code != http.StatusOK || res.Header().Get("ETag") == etagA {
+		t.Fatalf("expected 200 with a new ETag, got %d '%s'", res.Code, res.Header().Get("ETag"))
+	}
+}
+
+// noSeekFS struct wraps a filesystem hiding the Seek method of its files.
+type noSeekFS struct{ fs.FS }
+
+func (f noSeekFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return struct{ fs.File }{file}, nil
+}
+
+func TestFileNoSeek(t *testing.T) {
+	modtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := noSeekFS{fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<h1>index</h1>"), ModTime: modtime},
+	}}
+	handler := NewHandler(nil)
+	_ = handler.File("/index", fsys, "index.html")
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/index", nil))
+	if res.Code != http.StatusOK || res.Body.String() != "<h1>index</h1>" {
+		t.Fatalf("expected 200 with '<h1>index</h1>', got %d '%s'", res.Code, res.Body.String())
+	}
+	if ctype := res.Header().Get("Content-Type"); !strings.HasPrefix(ctype, "text/html") {
+		t.Fatalf("expected 'text/html' content type, got '%s'", ctype)
+	}
+	etag := res.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("expected strong ETag header, got '%s'", etag)
+	}
+
+	// the cached tag is reused while the file is streamed
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/index", nil))
+	if res.Body.String() != "<h1>index</h1>" || res.Header().Get("ETag") != etag {
+		t.Fatalf("expected '<h1>index</h1>' with ETag '%s', got '%s' %v", etag, res.Body.String(), res.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/index", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	if res.Code != http.StatusNotModified || res.Body.Len() != 0 {
+		t.Fatalf("expected 304 without body, got %d '%s'", res.Code, res.Body.String())
+	}
+}
+
+func TestStatic(t *testing.T) {
+	root := t.TempDir()
+	public := filepath.Join(root, "public")
+	if err := os.MkdirAll(filepath.Join(public, "css"), 0o755); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(public, "css", "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	handler := NewHandler(nil)
+	if err := handler.Static("/assets", public); err != nil {
+		t.Fatalf("expected nil, got %s", err)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/assets/css/app.css", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "body{}" {
+		t.Fatalf("expected 'body{}', got '%s'", body)
+	}
+	if contentType := res.Header().Get("Content-Type"); contentType != "text/css; charset=utf-8" {
+		t.Fatalf("expected 'text/css; charset=utf-8', got '%s'", contentType)
+	}
+
+	for _, uri := range []string{"/assets/missing.css", "/assets/css"} {
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+		if res.Code != http.StatusNotFound {
+			t.Fatalf("expected 404 for '%s', got %d", uri, res.Code)
+		}
+	}
+
+	for _, uri := range []string{"/assets/../secret.txt", "/assets/css/../../secret.txt", "/assets/%2e%2e/secret.txt", "/assets/..%2fsecret.txt"} {
+		res = httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for '%s', got %d", uri, res.Code)
+		}
+	}
+}
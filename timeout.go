@@ -0,0 +1,117 @@
+package apihandler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// timeoutWriter struct wraps an http.ResponseWriter to guard the response of
+// a handler that may keep running after its timeout. The handler writes its
+// headers to its own map, which is copied to the wrapped writer when the
+// status is written, and every write is discarded once the timeout fires, so
+// the response is written only once.
+type timeoutWriter struct {
+	w        http.ResponseWriter
+	header   http.Header
+	mtx      sync.Mutex
+	wrote    bool
+	timedOut bool
+}
+
+// Header method returns the headers of the handler, which are only sent if it
+// writes the status before the timeout fires.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader method writes the provided status code with the headers of the
+// handler, unless the headers were already written or the timeout fired.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+	tw.writeHeader(status)
+}
+
+// writeHeader method writes the provided status code if the headers were not
+// written yet and the timeout did not fire. It must be called holding the
+// mutex.
+func (tw *timeoutWriter) writeHeader(status int) {
+	if tw.wrote || tw.timedOut {
+		return
+	}
+	tw.wrote = true
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	tw.w.WriteHeader(status)
+}
+
+// Write method writes the provided data, writing the headers with a 200 HTTP
+// status first if they were not written yet. It returns
+// http.ErrHandlerTimeout if the timeout already fired.
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+	return tw.w.Write(data)
+}
+
+// timeout method marks the response as timed out, discarding any later write
+// of the handler, and responds with a 503 HTTP error if the handler did not
+// write the headers yet.
+func (tw *timeoutWriter) timeout() {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+	if !tw.wrote {
+		tw.wrote = true
+		http.Error(tw.w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}
+	tw.timedOut = true
+}
+
+// TimeoutMiddleware function returns a Middleware that runs the wrapped
+// handler with a request context that is cancelled after the provided
+// duration. If the handler does not finish in time, it responds with a 503
+// HTTP error (unless the handler already started its response) and any later
+// write of the handler is discarded, returning http.ErrHandlerTimeout. If the
+// handler finishes without writing its response, its headers are sent with a
+// 200 HTTP status, as net/http does. The handler should stop its work when
+// its request context is done. Panics of the handler are propagated to the
+// caller of the middleware.
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if rec := recover(); rec != nil {
+						panicked <- rec
+					}
+				}()
+				next(tw, r.WithContext(ctx))
+				close(done)
+			}()
+			select {
+			case <-done:
+				// send the headers of the handlers that do not write
+				// the status nor the body
+				tw.WriteHeader(http.StatusOK)
+			case rec := <-panicked:
+				panic(rec)
+			case <-ctx.Done():
+				tw.timeout()
+			}
+		}
+	}
+}
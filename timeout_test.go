@@ -0,0 +1,79 @@
+package apihandler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	timeout := TimeoutMiddleware(50 * time.Millisecond)
+
+	handler := timeout(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "fast")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("fast"))
+	})
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", res.Code)
+	}
+	if body := res.Body.String(); body != "fast" {
+		t.Fatalf("expected 'fast', got '%s'", body)
+	}
+	if header := res.Header().Get("X-Test"); header != "fast" {
+		t.Fatalf("expected 'fast', got '%s'", header)
+	}
+
+	// the headers are sent even if the handler writes nothing else
+	handler = timeout(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Foo", "bar")
+	})
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusOK || res.Header().Get("X-Foo") != "bar" {
+		t.Fatalf("expected 200 with 'X-Foo' header, got %d %v", res.Code, res.Header())
+	}
+
+	lateErr := make(chan error, 1)
+	handler = timeout(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("X-Test", "slow")
+		_, err := w.Write([]byte("slow"))
+		lateErr <- err
+	})
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", res.Code)
+	}
+	select {
+	case err := <-lateErr:
+		if !errors.Is(err, http.ErrHandlerTimeout) {
+			t.Fatalf("expected http.ErrHandlerTimeout, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the handler to finish")
+	}
+	if body := res.Body.String(); body != http.StatusText(http.StatusServiceUnavailable)+"\n" {
+		t.Fatalf("expected timeout body, got '%s'", body)
+	}
+	if header := res.Header().Get("X-Test"); header != "" {
+		t.Fatalf("expected no header, got '%s'", header)
+	}
+}
+
+func TestTimeoutMiddlewarePanic(t *testing.T) {
+	handler := Recovery()(TimeoutMiddleware(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		panic("test")
+	}))
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodGet, testURI, nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", res.Code)
+	}
+}
@@ -0,0 +1,175 @@
+package apihandler
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// node struct is a node of the prefix tree used to index the registered routes
+// by the segments of their paths (split by '/'). Literal segments are stored
+// as static children, accessed by map lookup, while segments with named
+// arguments or regex metacharacters fall through to a single dynamic child.
+// The routes whose path ends at a node are stored in that node, and the
+// prefix routes and the routes whose catch-all argument starts after it are
+// stored apart.
+type node struct {
+	static   map[string]*node
+	dynamic  *node
+	routes   []*route
+	catchAll []*route
+	prefix   []*route
+}
+
+// newNode function returns an empty node ready to use.
+func newNode() *node {
+	return &node{static: map[string]*node{}}
+}
+
+// isLiteralSegment function returns if the provided path segment can be
+// matched by string comparison, which means that it has no named arguments
+// nor regex metacharacters.
+func isLiteralSegment(segment string) bool {
+	return !strings.Contains(segment, "{") && regexp.QuoteMeta(segment) == segment
+}
+
+// uriSegments function splits the provided request URI into its segments,
+// ignoring the trailing separator as the routes matching does.
+func uriSegments(requestURI string) []string {
+	uri, _ := strings.CutSuffix(requestURI, uriSeparator)
+	return strings.Split(uri, uriSeparator)
+}
+
+// insert method stores the provided route in the node of the tree that
+// corresponds to its path, creating the intermediate nodes if they do not
+// exist. If the route ends with an optional argument, it is also stored in
+// the parent node of its last segment, and if it ends with a catch-all
+// argument, it is stored as a catch-all route of that parent node. The prefix
+// routes are stored as prefix routes of the node of their last segment.
+func (n *node) insert(r *route) {
+	current := n
+	segments := uriSegments(r.path)
+	for i, segment := range segments {
+		if i == len(segments)-1 {
+			if r.catchAll {
+				current.catchAll = addRoute(current.catchAll, r)
+				return
+			}
+			if r.optional {
+				current.routes = addRoute(current.routes, r)
+			}
+		}
+		if isLiteralSegment(segment) {
+			child, ok := current.static[segment]
+			if !ok {
+				child = newNode()
+				current.static[segment] = child
+			}
+			current = child
+			continue
+		}
+		if current.dynamic == nil {
+			current.dynamic = newNode()
+		}
+		current = current.dynamic
+	}
+	if r.prefix {
+		current.prefix = addRoute(current.prefix, r)
+		return
+	}
+	current.routes = addRoute(current.routes, r)
+}
+
+// addRoute function appends the provided route to the provided list of routes
+// and returns it. If there is already a route with the same method and path,
+// it is replaced.
+func addRoute(routes []*route, r *route) []*route {
+	for i, existing := range routes {
+		if existing.method == r.method && existing.path == r.path {
+			routes[i] = r
+			return routes
+		}
+	}
+	return append(routes, r)
+}
+
+// remove method deletes the provided route from the node of the tree that
+// corresponds to its path, and from the parent node of its last segment if it
+// ends with an optional or catch-all argument, as insert stores it. The nodes
+// are kept even if they become empty.
+func (n *node) remove(r *route) {
+	current := n
+	segments := uriSegments(r.path)
+	for i, segment := range segments {
+		if i == len(segments)-1 {
+			if r.catchAll {
+				current.catchAll = deleteRoute(current.catchAll, r)
+				return
+			}
+			if r.optional {
+				current.routes = deleteRoute(current.routes, r)
+			}
+		}
+		next := current.dynamic
+		if isLiteralSegment(segment) {
+			next = current.static[segment]
+		}
+		if next == nil {
+			return
+		}
+		current = next
+	}
+	if r.prefix {
+		current.prefix = deleteRoute(current.prefix, r)
+		return
+	}
+	current.routes = deleteRoute(current.routes, r)
+}
+
+// deleteRoute function removes the routes with the same method and path as
+// the provided one from the provided list of routes and returns it.
+func deleteRoute(routes []*route, r *route) []*route {
+	return slices.DeleteFunc(routes, func(existing *route) bool {
+		return existing.method == r.method && existing.path == r.path
+	})
+}
+
+// lookup method returns the enabled route for the provided method that passes
+// the provided test, traversing the tree by the provided URI segments.
+// Static children are checked before the dynamic ones but, to keep the
+// registration order precedence, a route is only checked if it was registered
+// before the best one found so far, so static routes are resolved without
+// running any regex unless they are shadowed by older dynamic routes. The
+// prefix routes of every traversed node are also checked, as the catch-all
+// ones while there are segments left.
+func (n *node) lookup(segments []string, method string, test func(*route) bool, best *route) *route {
+	best = bestRoute(n.prefix, method, test, best)
+	if len(segments) == 0 {
+		return bestRoute(n.routes, method, test, best)
+	}
+	best = bestRoute(n.catchAll, method, test, best)
+	if child, ok := n.static[segments[0]]; ok {
+		best = child.lookup(segments[1:], method, test, best)
+	}
+	if n.dynamic != nil {
+		best = n.dynamic.lookup(segments[1:], method, test, best)
+	}
+	return best
+}
+
+// bestRoute function returns the enabled route of the provided list for the
+// provided method that passes the provided test (usually matching a request
+// URI) and was registered before the provided best route, or the provided
+// best route if none does. The test is only called for the routes that would
+// replace the best one, so the last route that passes it is the returned one.
+func bestRoute(routes []*route, method string, test func(*route) bool, best *route) *route {
+	for _, r := range routes {
+		if r.method != method || r.disabled || (best != nil && r.seq >= best.seq) {
+			continue
+		}
+		if test(r) {
+			best = r
+		}
+	}
+	return best
+}
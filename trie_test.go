@@ -0,0 +1,119 @@
+package apihandler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestTrieLookup(t *testing.T) {
+	handler := NewHandler(nil)
+	// the constrained argument does not cover the static route for the
+	// conflict checks, so both are registered
+	_ = handler.Get("/users/{id:[a-z0-9]+}", testHandler)
+	_ = handler.Get("/users/me", testHandler)
+	_ = handler.Get("/files/{name}.json", testHandler)
+	_ = handler.Get("/static/path", testHandler)
+	_ = handler.Post("/static/path", testHandler)
+	_ = handler.Get("/", testHandler)
+	_ = handler.Get("/slash/", testHandler)
+
+	tests := []struct {
+		method, uri, expected string
+	}{
+		// the dynamic route was registered first, so it takes precedence
+		{http.MethodGet, "/users/me", "/users/{id:[a-z0-9]+}"},
+		{http.MethodGet, "/users/123", "/users/{id:[a-z0-9]+}"},
+		{http.MethodGet, "/files/report.json", "/files/{name}.json"},
+		{http.MethodGet, "/static/path", "/static/path"},
+		{http.MethodGet, "/static/path/", ""},
+		{http.MethodPost, "/static/path", "/static/path"},
+		{http.MethodGet, "/static", ""},
+		{http.MethodGet, "/static/path/other", ""},
+		{http.MethodGet, "/files/report.xml", ""},
+		{http.MethodPut, "/static/path", ""},
+		{http.MethodGet, "/", "/"},
+		{http.MethodGet, "/slash/", "/slash/"},
+	}
+	for _, test := range tests {
+		r, ok := handler.find(test.method, test.uri)
+		if test.expected == "" {
+			if ok {
+				t.Fatalf("expected no route for %s %s, got %s", test.method, test.uri, r.path)
+			}
+			continue
+		}
+		if !ok {
+			t.Fatalf("expected route %s for %s %s, got none", test.expected, test.method, test.uri)
+		}
+		if r.path != test.expected {
+			t.Fatalf("expected route %s for %s %s, got %s", test.expected, test.method, test.uri, r.path)
+		}
+	}
+
+	// overwriting a route keeps its registration order
+	_ = handler.Get("/users/{id:[a-z0-9]+}", testHandler)
+	if r, ok := handler.find(http.MethodGet, "/users/me"); !ok || r.path != "/users/{id:[a-z0-9]+}" {
+		t.Fatalf("expected overwritten route to keep its precedence")
+	}
+	// disabled routes are skipped
+	handler.DisableRoute(http.MethodGet, "/users/{id:[a-z0-9]+}")
+	if r, ok := handler.find(http.MethodGet, "/users/me"); !ok || r.path != "/users/me" {
+		t.Fatalf("expected static route after disabling the dynamic one")
+	}
+	if _, ok := handler.find(http.MethodGet, "/users/123"); ok {
+		t.Fatalf("expected no route for a disabled route")
+	}
+}
+
+// linearFind function emulates the route lookup by iterating over the list of
+// registered routes, to compare it with the tree lookup.
+func linearFind(m *Handler, method, requestURI string) (*route, bool) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	for _, r := range m.routes {
+		if r.method == method && !r.disabled && r.match(requestURI) {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+func benchmarkHandler(b *testing.B) *Handler {
+	handler := NewHandler(nil)
+	for i := 0; i < 250; i++ {
+		if err := handler.Get(fmt.Sprintf("/static/%d/resource", i), testHandler); err != nil {
+			b.Fatal(err)
+		}
+		if err := handler.Get(fmt.Sprintf("/dynamic/%d/{id}", i), testHandler); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return handler
+}
+
+func BenchmarkTrieFind(b *testing.B) {
+	handler := benchmarkHandler(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := handler.find(http.MethodGet, "/static/249/resource"); !ok {
+			b.Fatal("expected static route")
+		}
+		if _, ok := handler.find(http.MethodGet, "/dynamic/249/123"); !ok {
+			b.Fatal("expected dynamic route")
+		}
+	}
+}
+
+func BenchmarkLinearFind(b *testing.B) {
+	handler := benchmarkHandler(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := linearFind(handler, http.MethodGet, "/static/249/resource"); !ok {
+			b.Fatal("expected static route")
+		}
+		if _, ok := linearFind(handler, http.MethodGet, "/dynamic/249/123"); !ok {
+			b.Fatal("expected dynamic route")
+		}
+	}
+}
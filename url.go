@@ -0,0 +1,95 @@
+package apihandler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// URL method builds the path of the route registered with the provided name
+// using WithRouteName, replacing its named arguments with the provided params,
+// which are escaped. The optional arguments can be omitted, and the values of
+// the catch-all arguments can include separators. If no route has the
+// provided name, an error wrapping ErrUnknownRoute is returned. It also
+// returns an error if a required param is missing (wrapping
+// ErrURIParamNotFound), if a param is not an argument of the route or if its
+// value does not match the constraint of its argument.
+func (m *Handler) URL(name string, params map[string]string) (string, error) {
+	m.mtx.RLock()
+	path, found := "", false
+	for _, r := range m.routes {
+		if r.routeName == name && !r.implicit {
+			path, found = r.path, true
+			break
+		}
+	}
+	m.mtx.RUnlock()
+	if !found {
+		return "", fmt.Errorf("%w: '%s'", ErrUnknownRoute, name)
+	}
+	return buildPath(path, params)
+}
+
+// buildPath function replaces the named arguments of the provided route path
+// with the provided params, as `Handler.URL` describes.
+func buildPath(path string, params map[string]string) (string, error) {
+	var uri strings.Builder
+	used := map[string]bool{}
+	for {
+		start, end, ok := nextArg(path)
+		if !ok {
+			break
+		}
+		arg, rest := path[start+1:end], path[end+1:]
+		uri.WriteString(path[:start])
+		path = rest
+		// optional arguments are omitted with their separator
+		if name, ok := strings.CutSuffix(arg, "?"); ok {
+			used[name] = true
+			value := params[name]
+			if value == "" {
+				trimmed := strings.TrimSuffix(uri.String(), uriSeparator)
+				uri.Reset()
+				uri.WriteString(trimmed)
+				continue
+			}
+			uri.WriteString(url.PathEscape(value))
+			continue
+		}
+		// catch-all arguments keep their separators
+		if name, ok := strings.CutSuffix(arg, "..."); ok {
+			used[name] = true
+			value, ok := params[name]
+			if !ok || value == "" {
+				return "", fmt.Errorf("%w: '%s'", ErrURIParamNotFound, name)
+			}
+			segments := strings.Split(value, uriSeparator)
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			uri.WriteString(strings.Join(segments, uriSeparator))
+			continue
+		}
+		name, constraint, _ := strings.Cut(arg, ":")
+		used[name] = true
+		value, ok := params[name]
+		if !ok || value == "" {
+			return "", fmt.Errorf("%w: '%s'", ErrURIParamNotFound, name)
+		}
+		if constraint != "" {
+			rgx, err := regexp.Compile("^(?:" + constraint + ")$")
+			if err != nil || !rgx.MatchString(value) {
+				return "", fmt.Errorf("param '%s' does not match its constraint '%s'", name, constraint)
+			}
+		}
+		uri.WriteString(url.PathEscape(value))
+	}
+	uri.WriteString(path)
+	for name := range params {
+		if !used[name] {
+			return "", fmt.Errorf("unexpected param '%s'", name)
+		}
+	}
+	return uri.String(), nil
+}
@@ -0,0 +1,62 @@
+package apihandler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestURL(t *testing.T) {
+	handler := NewHandler(nil)
+	_ = handler.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(URIParam(r.Context(), "id")))
+	}, WithRouteName("user"))
+	_ = handler.Get("/users/{id:[0-9]+}/posts/{page?}", testHandler, WithRouteName("posts"))
+	_ = handler.Get("/files/{path...}", testHandler, WithRouteName("file"))
+	_ = handler.Get("/about", testHandler, WithRouteName("about"))
+
+	tests := []struct {
+		name     string
+		params   map[string]string
+		expected string
+	}{
+		{"user", map[string]string{"id": "42"}, "/users/42"},
+		{"user", map[string]string{"id": "john doe"}, "/users/john%20doe"},
+		{"posts", map[string]string{"id": "42", "page": "2"}, "/users/42/posts/2"},
+		{"posts", map[string]string{"id": "42"}, "/users/42/posts"},
+		{"file", map[string]string{"path": "docs/a b.txt"}, "/files/docs/a%20b.txt"},
+		{"about", nil, "/about"},
+	}
+	for _, test := range tests {
+		uri, err := handler.URL(test.name, test.params)
+		if err != nil {
+			t.Fatalf("expected nil for '%s', got %s", test.name, err)
+		}
+		if uri != test.expected {
+			t.Fatalf("expected '%s', got '%s'", test.expected, uri)
+		}
+	}
+
+	// the built URLs are served by their routes
+	uri, _ := handler.URL("user", map[string]string{"id": "john doe"})
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, uri, nil))
+	if body := res.Body.String(); body != "john doe" {
+		t.Fatalf("expected 'john doe', got '%s'", body)
+	}
+
+	if _, err := handler.URL("user", nil); !errors.Is(err, ErrURIParamNotFound) {
+		t.Fatalf("expected ErrURIParamNotFound, got %v", err)
+	}
+	if _, err := handler.URL("user", map[string]string{"id": "42", "other": "1"}); err == nil || !strings.Contains(err.Error(), "unexpected param 'other'") {
+		t.Fatalf("expected unexpected param error, got %v", err)
+	}
+	if _, err := handler.URL("posts", map[string]string{"id": "abc"}); err == nil || !strings.Contains(err.Error(), "constraint") {
+		t.Fatalf("expected constraint error, got %v", err)
+	}
+	if _, err := handler.URL("unknown", nil); !errors.Is(err, ErrUnknownRoute) {
+		t.Fatalf("expected ErrUnknownRoute, got %v", err)
+	}
+}